@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.iu.edu/evogelsa/go-ml-rpg/game"
+)
+
+// evolveCmd tunes the minmax weights with a genetic algorithm and saves the
+// best weights as a named profile which the server can load with -profile
+func evolveCmd(args []string) {
+	fs := flag.NewFlagSet("evolve", flag.ExitOnError)
+	name := fs.String("name", "tuned", "Name the best profile is saved"+
+		" under\n\t")
+	generations := fs.Int("generations", 30, "Number of generations to"+
+		" evolve\n\t")
+	population := fs.Int("population", 20, "Number of profiles in each"+
+		" generation\n\t")
+	matches := fs.Int("matches", 100, "Number of matches played against"+
+		" each reference agent to score a profile\n\t")
+	refs := fs.String("refs", "rand,minmax", "Comma separated reference"+
+		" agents profiles are scored against\n\t")
+	loadRules := rulesFlags(fs)
+
+	fs.Parse(args)
+	err := loadRules()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *population < 1 || *matches < 1 {
+		fmt.Println(errors.New("Evolving needs a population and matches of" +
+			" at least 1"))
+		os.Exit(1)
+	}
+
+	rand.Seed(time.Now().Unix())
+
+	var agents []game.Agent
+	for _, r := range strings.Split(*refs, ",") {
+		a, err := game.NewAgent(r)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		agents = append(agents, a)
+	}
+
+	best := game.EvolveMinMax(agents, *generations, *population, *matches,
+		func(gen int, prof game.MinMaxProfile, fitness float32) {
+			fmt.Printf("generation %d: fitness %.3f\n", gen, fitness)
+		})
+	best.Name = *name
+
+	err = game.SaveMinMaxProfile(best)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Printf("Saved profile %s%s.json\n", game.PROFILE_DIR, best.Name)
+}
@@ -0,0 +1,96 @@
+package game
+
+import "errors"
+
+// Agent is implemented by every AI strategy. GetTurn is always called with p
+// being the human player and e being the AI controlled character
+type Agent interface {
+	GetTurn(p, e *Class) Move
+}
+
+// Scorer is implemented by agents which are able to rank every move for a
+// given state. Higher scores are better for the AI
+type Scorer interface {
+	Scores(p, e *Class) []float32
+}
+
+// randAgent wraps the random strategy
+type randAgent struct{}
+
+func (randAgent) GetTurn(p, e *Class) Move {
+	return getTurnRand()
+}
+
+// minMaxAgent wraps the minmax strategy, weighted by profile or by the
+// default profile if nil
+type minMaxAgent struct {
+	profile *MinMaxProfile
+}
+
+func (a minMaxAgent) GetTurn(p, e *Class) Move {
+	return getTurnMinMax(p, e, a.profile)
+}
+
+func (a minMaxAgent) Scores(p, e *Class) []float32 {
+	return normalizedMinMaxes(p, e, a.profile)
+}
+
+// Greedy wraps a scorer into an agent which always plays its best scored move
+type Greedy struct {
+	Scorer
+}
+
+func (g Greedy) GetTurn(p, e *Class) Move {
+	return bestMove(g.Scores(p, e))
+}
+
+// agents maps names to constructors of the agents which can be selected as
+// opponents by the commands
+var agents = map[string]func() Agent{
+	"rand":   func() Agent { return randAgent{} },
+	"minmax": func() Agent { return minMaxAgent{} },
+	"policy": func() Agent { return serverPolicyAgent() },
+}
+
+// RegisterAgent makes an agent constructor available under name
+func RegisterAgent(name string, f func() Agent) {
+	agents[name] = f
+}
+
+// NewAgent returns a new agent registered under name
+func NewAgent(name string) (Agent, error) {
+	f, ok := agents[name]
+	if !ok {
+		return nil, errors.New("Could not parse agent " + name)
+	}
+	return f(), nil
+}
+
+// baseAgent returns the agent for the algorithm selected at server launch
+// which plays against player
+func baseAgent(player string) Agent {
+	if AI_ALG == AI_BANDIT {
+		return banditArm(player)
+	}
+	return algorithmAgent(AI_ALG, player)
+}
+
+// algorithmAgent returns the agent of algorithm alg which plays against
+// player
+func algorithmAgent(alg Algorithm, player string) Agent {
+	switch alg {
+	case AI_RAND:
+		return randAgent{}
+	case AI_REINFORCEMENT:
+		if PerPlayer {
+			return playerReinforcement(player)
+		}
+		return serverReinforcement()
+	case AI_DQN:
+		return serverDQN()
+	case AI_POLICY:
+		return serverPolicyAgent()
+	default:
+		return minMaxAgent{PROFILE}
+	}
+}
@@ -1,12 +1,8 @@
 package game
 
 import (
-	"encoding/gob"
-	"fmt"
 	"math"
 	"math/rand"
-	"os"
-	"sync"
 )
 
 type Algorithm int
@@ -15,8 +11,29 @@ const (
 	AI_MINMAX Algorithm = iota
 	AI_RAND
 	AI_REINFORCEMENT
+	AI_DQN
+	AI_POLICY
+	AI_BANDIT
 )
 
+func (a Algorithm) String() string {
+	switch a {
+	case AI_MINMAX:
+		return "minmax"
+	case AI_RAND:
+		return "rand"
+	case AI_REINFORCEMENT:
+		return "reinforcement"
+	case AI_DQN:
+		return "dqn"
+	case AI_POLICY:
+		return "policy"
+	case AI_BANDIT:
+		return "bandit"
+	}
+	return "unknown"
+}
+
 const (
 	phMask = 0x0300
 	paMask = 0x00C0
@@ -24,19 +41,15 @@ const (
 	eaMask = 0x0003
 )
 
+// Train, LearningRate, Discount, ExploreRate, Lambda and UPDATE_RULE are set
+// by main using cmd line flags and configure the reinforcement agent used by
+// the server
 var Train bool
 var LearningRate float32
 var Discount float32
-
 var ExploreRate float32
-var turns int
-var exploreMutex sync.Mutex
-
-var madeQT bool = false
-var madeLock sync.Mutex
-
-var QT map[uint16][]float32
-var qtMutex sync.RWMutex
+var Lambda float32
+var UPDATE_RULE UpdateRule = QLearning{}
 
 // AI_ALG is set by main which uses cmd line flags to
 // set the desired ai algorithm
@@ -48,68 +61,24 @@ func normalize(n, min, max float32) float32 {
 	return (n - min) / (max - min)
 }
 
-// initQT enumerates all states and initializes their qtable rows
-// to be zeros
-func initQT() {
-	qtMutex.Lock()
-	defer qtMutex.Unlock()
-
-	// check if qtable already exists
-	_, err := os.Stat("qtable")
-	if err == nil {
-		// file exists
-		loadQT()
-	} else {
-		QT = make(map[uint16][]float32)
-	}
-}
-
-// loadQT loads qTable from file. Only called from initQT since
-// there is no mutex enforcement within
-func loadQT() {
-	// only call from initQT
-	f, err := os.Open("qtable")
-	if err != nil {
-		panic(err)
-	}
-	defer f.Close()
-
-	dec := gob.NewDecoder(f)
-	err = dec.Decode(&QT)
-	if err != nil {
-		panic(err)
-	}
-}
-
-// saveQT saves qtable to disk
-func saveQT() {
-	qtMutex.RLock()
-	defer qtMutex.RUnlock()
-
-	f, err := os.Create("qtable")
-	if err != nil {
-		panic(err)
-	}
-	defer f.Close()
-
-	enc := gob.NewEncoder(f)
-	err = enc.Encode(QT)
-	if err != nil {
-		panic(err)
+// stateClass returns the index of the class called name as encoded in states,
+// unknown classes are encoded as the first class
+func stateClass(name string) uint32 {
+	i := classIndex(name)
+	if i < 0 {
+		return 0
 	}
+	return uint32(i)
 }
 
-func getState(p, e *Class) uint16 {
-	var state uint16
+func getState(p, e *Class) uint32 {
+	var state uint32
 
-	switch p.ClassName {
-	case "Knight":
-		state += 0 << 10
-	case "Archer":
-		state += 1 << 10
-	case "Wizard":
-		state += 2 << 10
-	}
+	// the low two bits of the class index keep the layout of tables trained
+	// with the original three classes, the high bit is stored above them
+	pc := stateClass(p.ClassName)
+	state += (pc & 3) << 10
+	state += (pc >> 2) << 12
 
 	if p.Health < 25 {
 		state += 0 << 8
@@ -127,14 +96,9 @@ func getState(p, e *Class) uint16 {
 		state += 2 << 6
 	}
 
-	switch e.ClassName {
-	case "Knight":
-		state += 0 << 4
-	case "Archer":
-		state += 1 << 4
-	case "Wizard":
-		state += 2 << 4
-	}
+	ec := stateClass(e.ClassName)
+	state += (ec & 3) << 4
+	state += (ec >> 2) << 13
 
 	if e.Health < 25 {
 		state += 0 << 2
@@ -152,129 +116,126 @@ func getState(p, e *Class) uint16 {
 		state += 2
 	}
 
-	return state
-}
+	// exhaustion is stored in the top bits so fresh characters keep the
+	// states of tables trained before stamina
+	if p.exhausted() {
+		state += 1 << 14
+	}
+	if e.exhausted() {
+		state += 1 << 15
+	}
 
-func updateQT(state, nextState uint16, action Move) {
-	if Train {
-		// lock QT for reading
-		qtMutex.RLock()
-		// get current q value
-		qv := QT[state][action]
-		// get max q future
-		qf := QT[nextState]
-		// unlock QT
-		qtMutex.RUnlock()
-
-		var max float32 = -math.MaxFloat32
-		for _, v := range qf {
-			if max < v {
-				max = v
-			}
-		}
-		// get reward (check if state values change)
-		var reward float32
-		// extract p health, if decrease + reward
-		ph := (state & phMask) >> 8
-		phNext := (nextState & phMask) >> 8
-		if phNext < ph {
-			reward += 1.5
+	// one bit for each effect either character is under
+	for k := BLEED; k < nEffects; k++ {
+		if p.has(k) {
+			state += 1 << (16 + uint(k))
 		}
-		// extract p armor, if decrease + reward
-		pa := (state & paMask) >> 6
-		paNext := (nextState & paMask) >> 6
-		if paNext < pa {
-			reward += 1.5
-		}
-		// extract e health, if increase + reward, if dec small penalty
-		eh := (state & ehMask) >> 2
-		ehNext := (nextState & ehMask) >> 2
-		if ehNext > eh {
-			reward += 1
-		} else if ehNext < eh {
-			reward -= .5
-		}
-		// extract e armor, if increase + reward, if dec small penalty
-		ea := (state & eaMask)
-		eaNext := (nextState & eaMask)
-		if eaNext > ea {
-			reward += 1
-		} else if eaNext < ea {
-			reward -= .5
+		if e.has(k) {
+			state += 1 << (16 + nEffects + uint(k))
 		}
+	}
 
-		// lock qt for writing
-		qtMutex.Lock()
-		QT[state][action] = qv + LearningRate*(reward+Discount*max-qv)
-		fmt.Printf("%f = ", QT[state][action])
-		fmt.Printf("%f + %f*(%f+%f*%f-%f)\n",
-			qv, LearningRate, reward, Discount, max, qv)
-		qtMutex.Unlock()
+	// whether either character fights with a weapon equipped
+	if p.Weapon != "" {
+		state += 1 << (16 + 2*nEffects)
+	}
+	if e.Weapon != "" {
+		state += 1 << (17 + 2*nEffects)
 	}
+
+	return state
 }
 
 // minMaxDamage returns array of avg outcome for each move wrt
 // damage dealt to player
 func minMaxDamage(p, e *Class) []float32 {
-	// actions: heavy, quick, standard, block, parry, evade
-	// calculate average damage to player of each attack
 	// chance of success * avg outcome = avg val
-	avgH := (1 - p.Intellect) * float32(int(10*e.Strength+1.5))
-	avgQ := (1 - p.Strength) * float32(int(10*e.Dexterity+1.5))
-	avgS := (1 - p.Dexterity) * float32(int(10*e.Intellect+1.5))
-	avgB := float32(0)
-	avgP := (e.Dexterity) * float32(int(10*e.Dexterity+.5))
-	avgE := float32(0)
-
-	return []float32{avgH, avgQ, avgS, avgB, avgP, avgE}
+	vals := make([]float32, 6)
+	for m := HEAVY; m <= EVADE; m++ {
+		def := MoveOf(e, m)
+		switch def.Outcome {
+		case ATTACK:
+			// calculate average damage to player of each attack, with
+			// the crits and glancing blows of the combat model, the
+			// traits of the class of e and its weapon
+			s := attribute(p, def.Success)
+			vals[m] = (1 - s) * float32(int(
+				float32(def.Base)/2*attribute(e, def.Damage)+1.5)) *
+				COMBAT.damageMult(e, def, s) *
+				scaleOf(traitsOf(e).AttackScale) * (1 + e.gear().Damage)
+		case COUNTER:
+			vals[m] = attribute(e, def.Success) * defenseAmount(e, def)
+		}
+	}
+	return vals
+}
+
+// defenseAmount returns the most defense def of e can roll
+func defenseAmount(e *Class, def MoveDef) float32 {
+	return float32(int(float32(def.Base)*attribute(e, def.Damage) + .5))
+}
+
+// avgDamageFrom returns the avg damage dealt by p across its moves
+func avgDamageFrom(p, e *Class) float32 {
+	var avg float32
+	for _, v := range minMaxDamage(e, p) {
+		avg += v
+	}
+	return avg / 6
 }
 
 // minMaxHealth returns array of avg outcome for each move wrt
 // change in enemy health
 func minMaxHealth(p, e *Class) []float32 {
-	avgsFromPlayer := minMaxDamage(e, p)
-	var avgPlayer float32
-	for _, v := range avgsFromPlayer {
-		avgPlayer += v
+	avgPlayer := avgDamageFrom(p, e)
+
+	vals := make([]float32, 6)
+	for m := HEAVY; m <= EVADE; m++ {
+		def := MoveOf(e, m)
+		s := attribute(e, def.Success)
+		switch def.Outcome {
+		case ATTACK:
+			vals[m] = -avgPlayer
+		case REPAIR:
+			// enemy fail block prob * avg damage
+			vals[m] = (1 - s) * avgPlayer
+		case COUNTER:
+			// enemy fail parry prob * extra dmg + avg damage
+			vals[m] = -((1-s)*defenseAmount(e, def) + avgPlayer)
+		case HEAL:
+			// enemy evade prob * heal - enemy fail evade prob * avg damage
+			heal := defenseAmount(e, def) * scaleOf(traitsOf(e).HealScale)
+			vals[m] = s*heal - (1-s)*avgPlayer
+		}
 	}
-	avgPlayer /= 6
-
-	avgH := -avgPlayer
-	avgQ := -avgPlayer
-	avgS := -avgPlayer
-	// enemy fail block prob * avg damage
-	avgB := ((1 - e.Strength) * avgPlayer)
-	// enemy fail parry prob * extra dmg + avg damage
-	avgP := -(((1 - e.Dexterity) * float32(int(10*e.Dexterity+.5))) + avgPlayer)
-	// enemy evade prob * heal - enemy fail evade prob * avg damage
-	avgE := ((e.Intellect) * float32(int(10*e.Intellect+.5))) -
-		((1 - e.Intellect) * avgPlayer)
-
-	return []float32{avgH, avgQ, avgS, avgB, avgP, avgE}
+	return vals
 }
 
 // min/maxArmor gets avg outcome for each move wrt to change in
 // enemy armor
 func minMaxArmor(p, e *Class) []float32 {
-	avgsFromPlayer := minMaxDamage(e, p)
-	var avgPlayer float32
-	for _, v := range avgsFromPlayer {
-		avgPlayer += v
+	avgPlayer := avgDamageFrom(p, e)
+
+	vals := make([]float32, 6)
+	for m := HEAVY; m <= EVADE; m++ {
+		def := MoveOf(e, m)
+		s := attribute(e, def.Success)
+		switch def.Outcome {
+		case ATTACK:
+			vals[m] = -avgPlayer
+		case REPAIR:
+			// enemy block prob * avg repair - enemy fail block prob * avg
+			// damage
+			vals[m] = s*defenseAmount(e, def) - (1-s)*avgPlayer
+		case COUNTER:
+			// enemy fail parry prob * extra dmg + avg damage
+			vals[m] = -((1-s)*defenseAmount(e, def) + avgPlayer)
+		case HEAL:
+			// enemy fail evade prob * avg damage
+			vals[m] = -((1 - s) * avgPlayer)
+		}
 	}
-	avgPlayer /= 6
-
-	avgH := -avgPlayer
-	avgQ := -avgPlayer
-	avgS := -avgPlayer
-	// enemy block prob * avg heal - enemy fail block prob * avg damage
-	avgB := (((e.Strength) * float32(int(10*e.Strength+.5))) -
-		((1 - e.Strength) * avgPlayer))
-	// enemy fail parry prob * extra dmg + avg damage
-	avgP := -(((1 - e.Dexterity) * float32(int(10*e.Dexterity+.5))) + avgPlayer)
-	// enemy fail evade prob * avg damage
-	avgE := -((1 - e.Intellect) * avgPlayer)
-
-	return []float32{avgH, avgQ, avgS, avgB, avgP, avgE}
+	return vals
 }
 
 // getMinMaxAll calls the three minmax funcs and returns array containing
@@ -288,8 +249,11 @@ func getMinMaxAll(p, e *Class) [][]float32 {
 }
 
 // normalizedMinMaxes returns a normalized slice containing weights
-// for each move, sums to 1
-func normalizedMinMaxes(p, e *Class) []float32 {
+// for each move weighted by profile prof, sums to 1
+func normalizedMinMaxes(p, e *Class, prof *MinMaxProfile) []float32 {
+	if prof == nil {
+		prof = &defaultProfile
+	}
 	minMaxes := getMinMaxAll(p, e)
 
 	var max float32 = -math.MaxFloat32
@@ -313,6 +277,7 @@ func normalizedMinMaxes(p, e *Class) []float32 {
 	for i, mm := range minMaxes {
 		for j := range mm {
 			minMaxes[i][j] = normalize(minMaxes[i][j], min, max)
+			minMaxes[i][j] *= prof.Tables[i]
 		}
 	}
 
@@ -340,13 +305,13 @@ func normalizedMinMaxes(p, e *Class) []float32 {
 
 	var ret []float32 = vals[:6]
 
-	return ret
+	return prof.weighMoves(ret)
 }
 
 // getTurnMinMax uses the minmax strategy to determine weighted probabilities
 // for each move and pseudorandomly selects the move to use based on weights
-func getTurnMinMax(p, e *Class) Move {
-	minMaxes := normalizedMinMaxes(p, e)
+func getTurnMinMax(p, e *Class, prof *MinMaxProfile) Move {
+	minMaxes := normalizedMinMaxes(p, e, prof)
 
 	r := rand.Float32()
 	var m int
@@ -365,55 +330,37 @@ func getTurnRand() Move {
 	return Move(rand.Intn(6))
 }
 
-func getTurnReinforcement(p, e *Class) Move {
-	// lock mutex here to prevent two possible inits
-	madeLock.Lock()
-	if !madeQT {
-		initQT()
-		madeQT = true
-		fmt.Println("QT initialized")
-	} else {
-		saveQT()
-	}
-	madeLock.Unlock()
-
-	// get state
-	state := getState(p, e)
-	// select next action, check explore or exploit
-	exploreMutex.Lock()
-	var action Move
-	if rand.Float32() < ExploreRate {
-		turns++
-		action = getTurnRand()
-		if ExploreRate > .25 {
-			ExploreRate -= (float32(turns) * .001)
-		}
-	} else {
-		var max float32 = -math.MaxFloat32
-		for i, v := range QT[state] {
-			if max < v {
-				max = v
-				action = Move(i)
-			}
-		}
+// AIGetTurn handles getting the next move of the AI using whatever strategy
+// was selected at server launch, weakened to the difficulty chosen by p and
+// limited to the moves e has the stamina for. It also returns an explanation
+// of the move made as it was chosen
+func AIGetTurn(p, e *Class) (Move, Explanation) {
+	base, name := aiAgent(p)
+	ex, scores := explainTurn(base, name, p, e)
+
+	agent := WithDifficulty(base, p.Difficulty, p.PlayerName)
+	m, weakened := difficultyTurn(agent, p, e)
+	if !e.CanAfford(m) {
+		m = bestLegal(e, scores, sharedDice{})
+		ex.Exhausted = true
 	}
-	exploreMutex.Unlock()
-	return action
-}
 
-// aiGetTurn handles getting the next move of the AI using whatever strategy was
-// selected at server launch
-func AIGetTurn(p, e *Class) Move {
-	var m Move
+	ex.Move = m.String()
+	ex.Weakened = weakened
+	ex.Explored = ex.Best != "" && !weakened && !ex.Exhausted &&
+		ex.Move != ex.Best
+	return m, ex
+}
 
-	switch AI_ALG {
-	case AI_MINMAX:
-		m = getTurnMinMax(p, e)
-	case AI_RAND:
-		m = getTurnRand()
-	case AI_REINFORCEMENT:
-		m = getTurnReinforcement(p, e)
+// aiAgent returns the agent playing against p and its name, which is a
+// counter strategy instead of the selected one while p is exploiting the AI
+func aiAgent(p *Class) (Agent, string) {
+	if counter, ok := counterFor(p.PlayerName); ok {
+		return counter, "counter"
 	}
-
-	return m
+	if AI_ALG == AI_BANDIT {
+		arm := ActiveArm(p.PlayerName)
+		return armAgent(arm, p.PlayerName), "bandit " + arm
+	}
+	return baseAgent(p.PlayerName), AI_ALG.String()
 }
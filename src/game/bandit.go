@@ -0,0 +1,233 @@
+package game
+
+import (
+	"encoding/gob"
+	"errors"
+	"math"
+	"os"
+	"sync"
+)
+
+// BanditObjective is what the bandit meta agent rewards its arms for
+type BanditObjective int
+
+const (
+	BANDIT_CHALLENGE BanditObjective = iota // close matches
+	BANDIT_WIN                              // AI wins
+)
+
+// BanditArms and BANDIT_OBJECTIVE are set by main using cmd line flags and
+// configure the bandit meta agent. Arms are algorithm names or the names of
+// registered agents
+var BanditArms = []string{"rand", "minmax", "reinforcement"}
+var BANDIT_OBJECTIVE BanditObjective
+
+// banditWeight is the exploration bonus weight of the ucb1 arm selection
+const banditWeight = 1.4
+
+// armStats are the results of one arm against one player
+type armStats struct {
+	Plays  int
+	Reward float32 // summed rewards
+}
+
+// playerBandit holds the arm statistics of one player and the arm playing
+// their current match
+type playerBandit struct {
+	Arms   map[string]*armStats
+	Active string // arm of the match in progress, empty between matches
+}
+
+var bandits map[string]*playerBandit
+var banditLock sync.Mutex
+
+// ParseBanditObjective converts an objective name into a BanditObjective
+func ParseBanditObjective(s string) (BanditObjective, error) {
+	switch s {
+	case "challenge":
+		return BANDIT_CHALLENGE, nil
+	case "win":
+		return BANDIT_WIN, nil
+	}
+	return BANDIT_CHALLENGE, errors.New("Could not parse bandit objective")
+}
+
+// ValidateArm returns an error if name is neither an algorithm nor a
+// registered agent
+func ValidateArm(name string) error {
+	if _, ok := parseAlgorithm(name); ok {
+		return nil
+	}
+	_, err := NewAgent(name)
+	return err
+}
+
+// parseAlgorithm returns the algorithm called name
+func parseAlgorithm(name string) (Algorithm, bool) {
+	for _, alg := range []Algorithm{AI_MINMAX, AI_RAND, AI_REINFORCEMENT,
+		AI_DQN, AI_POLICY} {
+		if alg.String() == name {
+			return alg, true
+		}
+	}
+	return AI_MINMAX, false
+}
+
+// armAgent returns the agent of the arm called name playing against player
+func armAgent(name, player string) Agent {
+	if alg, ok := parseAlgorithm(name); ok {
+		return algorithmAgent(alg, player)
+	}
+	a, err := NewAgent(name)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// selectArm picks the arm with the highest ucb1 bound, playing every arm
+// once first
+func (b *playerBandit) selectArm() string {
+	var total int
+	for _, name := range BanditArms {
+		s, ok := b.Arms[name]
+		if !ok || s.Plays == 0 {
+			return name
+		}
+		total += s.Plays
+	}
+
+	best, bestBound := BanditArms[0], -math.MaxFloat64
+	for _, name := range BanditArms {
+		s := b.Arms[name]
+		mean := float64(s.Reward) / float64(s.Plays)
+		bound := mean + banditWeight*
+			math.Sqrt(math.Log(float64(total))/float64(s.Plays))
+		if bound > bestBound {
+			best, bestBound = name, bound
+		}
+	}
+	return best
+}
+
+// update adds reward to the active arm and ends the match
+func (b *playerBandit) update(reward float32) {
+	s, ok := b.Arms[b.Active]
+	if !ok {
+		s = &armStats{}
+		b.Arms[b.Active] = s
+	}
+	s.Plays++
+	s.Reward += reward
+	b.Active = ""
+}
+
+// banditReward rewards the arm for the final characters p and e of a match
+// according to BANDIT_OBJECTIVE. Challenge rewards the closer the winner was
+// to dying, relative to the health cap of its level and gear
+func banditReward(p, e *Class) float32 {
+	if BANDIT_OBJECTIVE == BANDIT_WIN {
+		if e.Health > 0 && p.Health <= 0 {
+			return 1
+		}
+		return 0
+	}
+
+	winner := p
+	if e.Health > p.Health {
+		winner = e
+	}
+	if winner.Health <= 0 {
+		return 1
+	}
+	reward := 1 - float32(winner.Health)/float32(winner.HealthCap())
+	if reward < 0 {
+		reward = 0
+	}
+	return reward
+}
+
+// getBandit returns the bandit of player, creating one if needed. Only call
+// with banditLock held
+func getBandit(player string) *playerBandit {
+	if bandits == nil {
+		loadBandits()
+	}
+	b, ok := bandits[player]
+	if !ok {
+		b = &playerBandit{Arms: make(map[string]*armStats)}
+		bandits[player] = b
+	}
+	return b
+}
+
+// banditArm returns the agent of the arm playing the current match of
+// player, selecting one when a new match starts
+func banditArm(player string) Agent {
+	return armAgent(ActiveArm(player), player)
+}
+
+// ActiveArm returns the name of the arm playing the current match of player,
+// selecting one when a new match starts
+func ActiveArm(player string) string {
+	banditLock.Lock()
+	defer banditLock.Unlock()
+
+	b := getBandit(player)
+	if b.Active == "" {
+		b.Active = b.selectArm()
+		saveBandits()
+	}
+	return b.Active
+}
+
+// RecordBanditResult rewards the arm which played the match of player p
+// against e that just ended
+func RecordBanditResult(p, e *Class) {
+	if AI_ALG != AI_BANDIT {
+		return
+	}
+
+	banditLock.Lock()
+	defer banditLock.Unlock()
+
+	b := getBandit(p.PlayerName)
+	if b.Active == "" {
+		return
+	}
+	b.update(banditReward(p, e))
+	saveBandits()
+}
+
+// loadBandits loads bandit stats from file if it exists. Only call with
+// banditLock held
+func loadBandits() {
+	bandits = make(map[string]*playerBandit)
+
+	f, err := os.Open("bandit")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(f)
+	err = dec.Decode(&bandits)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// saveBandits saves bandit stats to disk. Only call with banditLock held
+func saveBandits() {
+	f, err := os.Create("bandit")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	enc := gob.NewEncoder(f)
+	err = enc.Encode(bandits)
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,57 @@
+package game
+
+import "testing"
+
+// TestBanditSelect checks that every arm is tried once and the best arm is
+// then preferred
+func TestBanditSelect(t *testing.T) {
+	b := &playerBandit{Arms: make(map[string]*armStats)}
+
+	seen := make(map[string]bool)
+	for range BanditArms {
+		b.Active = b.selectArm()
+		seen[b.Active] = true
+		b.update(0)
+	}
+	if len(seen) != len(BanditArms) {
+		t.Fatalf("Tried %d arms, expected %d", len(seen), len(BanditArms))
+	}
+
+	best := BanditArms[1]
+	picked := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		b.Active = b.selectArm()
+		picked[b.Active]++
+		if b.Active == best {
+			b.update(1)
+		} else {
+			b.update(0)
+		}
+	}
+	if picked[best] < 150 {
+		t.Errorf("Picked best arm %d of 200 times", picked[best])
+	}
+}
+
+// TestBanditReward checks both objectives
+func TestBanditReward(t *testing.T) {
+	p := &Class{Health: 0}
+	near := &Class{Health: 10}
+	easy := &Class{Health: 90}
+
+	BANDIT_OBJECTIVE = BANDIT_CHALLENGE
+	if banditReward(p, near) <= banditReward(p, easy) {
+		t.Errorf("Close match not rewarded more than an easy one")
+	}
+	// a level 6 winner left with 120 of its 150 health had 20% to lose
+	leveled := &Class{Health: 120, Level: 6}
+	if r := banditReward(p, leveled); r < .19 || r > .21 {
+		t.Errorf("Got reward %.2f for a leveled winner, expected .2", r)
+	}
+
+	BANDIT_OBJECTIVE = BANDIT_WIN
+	defer func() { BANDIT_OBJECTIVE = BANDIT_CHALLENGE }()
+	if banditReward(p, easy) != 1 || banditReward(easy, p) != 0 {
+		t.Errorf("Win objective does not reward AI wins only")
+	}
+}
@@ -0,0 +1,304 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// CLASSES_FILE is the file the class registry is loaded from by default
+const CLASSES_FILE = "classes.json"
+
+// maxClasses is the most classes getState can tell apart
+const maxClasses = 8
+
+// StatRange is the inclusive range a stat of a new character is rolled in.
+// Attributes are rolled in steps of .05
+type StatRange struct {
+	Min float32
+	Max float32
+}
+
+// ClassDef defines a class characters can be created with
+type ClassDef struct {
+	Name      string
+	Health    StatRange
+	Stamina   StatRange // zero for characters without stamina
+	Armor     StatRange
+	Strength  StatRange
+	Dexterity StatRange
+	Intellect StatRange
+	Moves     [6]string // names of the moves in the registry of each slot
+	Sprite    string    // prefix of the sprite images of the class
+	Traits    ClassTraits
+}
+
+// ClassTraits scale all moves of a class. The zero value plays like the
+// original classes
+type ClassTraits struct {
+	AttackScale float32 `json:",omitempty"` // scales attack damage, 0 is 1
+	HealScale   float32 `json:",omitempty"` // scales heals, 0 is 1
+}
+
+// classes is the class registry, in the order classes are encoded in states
+var classes = DefaultClasses()
+
+// DefaultClasses returns the knight, archer, wizard, rogue and cleric classes
+// which are also shipped in CLASSES_FILE
+func DefaultClasses() []ClassDef {
+	return []ClassDef{
+		{
+			Name:      "Knight",
+			Health:    StatRange{80, 100},
+			Stamina:   StatRange{50, 70},
+			Armor:     StatRange{0, 20},
+			Strength:  StatRange{.75, 1},
+			Dexterity: StatRange{.5, .75},
+			Intellect: StatRange{.25, .5},
+			Moves: [6]string{"Crushing Blow", "Quick Thrust", "Sword Slash",
+				"Shield", "Counter", "Drink Potion"},
+			Sprite: "Knight",
+		},
+		{
+			Name:      "Archer",
+			Health:    StatRange{80, 100},
+			Stamina:   StatRange{50, 70},
+			Armor:     StatRange{0, 20},
+			Strength:  StatRange{.25, .5},
+			Dexterity: StatRange{.75, 1},
+			Intellect: StatRange{.5, .75},
+			Moves: [6]string{"Piercing Shot", "Quick Fire", "Long Shot",
+				"Block", "Dagger", "Apply Bandaid"},
+			Sprite: "Archer",
+		},
+		{
+			Name:      "Wizard",
+			Health:    StatRange{80, 100},
+			Stamina:   StatRange{50, 70},
+			Armor:     StatRange{0, 20},
+			Strength:  StatRange{.5, .75},
+			Dexterity: StatRange{.25, .5},
+			Intellect: StatRange{.75, 1},
+			Moves: [6]string{"Lightning", "Arcane Bolt", "Fireball",
+				"Magic Shield", "Counterspell", "Heal"},
+			Sprite: "Wizard",
+		},
+		{
+			Name:      "Rogue",
+			Health:    StatRange{70, 90},
+			Stamina:   StatRange{60, 80},
+			Armor:     StatRange{0, 15},
+			Strength:  StatRange{.5, .75},
+			Dexterity: StatRange{.75, 1},
+			Intellect: StatRange{.25, .5},
+			Moves: [6]string{"Ambush", "Backstab", "Throwing Knives", "Cloak",
+				"Riposte", "Vanish"},
+			Sprite: "Rogue",
+		},
+		{
+			Name:      "Cleric",
+			Health:    StatRange{90, 100},
+			Stamina:   StatRange{50, 70},
+			Armor:     StatRange{5, 20},
+			Strength:  StatRange{.5, .75},
+			Dexterity: StatRange{.25, .5},
+			Intellect: StatRange{.5, .75},
+			Moves: [6]string{"Smite", "Mace Strike", "Holy Light", "Sanctuary",
+				"Rebuke", "Prayer"},
+			Sprite: "Cleric",
+			Traits: ClassTraits{
+				AttackScale: .75,
+				HealScale:   2,
+			},
+		},
+	}
+}
+
+// LoadClasses replaces the class registry with the classes in file fn
+func LoadClasses(fn string) error {
+	body, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return err
+	}
+
+	var defs []ClassDef
+	err = json.Unmarshal(body, &defs)
+	if err != nil {
+		return err
+	}
+
+	err = validateClasses(defs)
+	if err != nil {
+		return fmt.Errorf("%s: %v", fn, err)
+	}
+	classes = defs
+	return nil
+}
+
+// validateClasses checks that defs can be used as the class registry,
+// defaulting empty sprite prefixes to the class name
+func validateClasses(defs []ClassDef) error {
+	if len(defs) == 0 {
+		return errors.New("No classes defined")
+	}
+	if len(defs) > maxClasses {
+		return fmt.Errorf("%d classes defined, at most %d are supported",
+			len(defs), maxClasses)
+	}
+
+	seen := make(map[string]bool)
+	for i := range defs {
+		def := &defs[i]
+		if def.Name == "" {
+			return fmt.Errorf("Class %d has no name", i)
+		}
+		if seen[def.Name] {
+			return fmt.Errorf("Class %s defined twice", def.Name)
+		}
+		seen[def.Name] = true
+
+		if def.Sprite == "" {
+			def.Sprite = def.Name
+		}
+		for i, name := range def.Moves {
+			m, ok := LookupMove(name)
+			if !ok {
+				return fmt.Errorf("Class %s has unknown move %q", def.Name,
+					name)
+			}
+			if (m.Outcome == ATTACK) != (Move(i) < BLOCK) {
+				return fmt.Errorf("Class %s has %s in the wrong slot, attacks"+
+					" go in the first three", def.Name, name)
+			}
+		}
+
+		if def.Health.Min < 1 || def.Health.Max > 100 ||
+			def.Armor.Min < 0 || def.Armor.Max > 20 ||
+			def.Stamina.Min < 0 || def.Stamina.Max > 100 {
+			return fmt.Errorf("Class %s health, stamina or armor out of"+
+				" bounds", def.Name)
+		}
+		for _, r := range []StatRange{def.Health, def.Stamina, def.Armor,
+			def.Strength, def.Dexterity, def.Intellect} {
+			if r.Min > r.Max {
+				return fmt.Errorf("Class %s has a range with min above max",
+					def.Name)
+			}
+		}
+		for _, r := range []StatRange{def.Strength, def.Dexterity,
+			def.Intellect} {
+			if r.Min < 0 || r.Max > 1 {
+				return fmt.Errorf("Class %s attributes out of bounds",
+					def.Name)
+			}
+		}
+
+		err := def.Traits.validate()
+		if err != nil {
+			return fmt.Errorf("Class %s %v", def.Name, err)
+		}
+	}
+	return nil
+}
+
+// validate checks that the traits scale by sensible amounts
+func (t ClassTraits) validate() error {
+	if t.AttackScale < 0 || t.AttackScale > 2 || t.HealScale < 0 ||
+		t.HealScale > 3 {
+		return errors.New("has trait scales out of bounds")
+	}
+	return nil
+}
+
+// traitsOf returns the traits of the class of c, unknown classes have none
+func traitsOf(c *Class) ClassTraits {
+	def, _ := LookupClass(c.ClassName)
+	return def.Traits
+}
+
+// scale multiplies a successful roll n by s, leaving it at least 1 so the
+// roll stays successful
+func scale(n int, s float32) int {
+	if n <= 0 || s == 0 {
+		return n
+	}
+	scaled := int(float32(n)*s + .5)
+	if scaled < 1 {
+		return 1
+	}
+	return scaled
+}
+
+// scaleOf returns the factor trait scale s multiplies rolls by on average
+func scaleOf(s float32) float32 {
+	if s == 0 {
+		return 1
+	}
+	return s
+}
+
+// Classes returns the class registry
+func Classes() []ClassDef {
+	return classes
+}
+
+// LookupClass returns the definition of the class called name
+func LookupClass(name string) (ClassDef, bool) {
+	for _, def := range classes {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return ClassDef{}, false
+}
+
+// classIndex returns the position of the class called name in the registry,
+// or -1 if there is no such class
+func classIndex(name string) int {
+	for i, def := range classes {
+		if def.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// NewClass generates a new character of the class called className
+func NewClass(className, playerName string) (Class, error) {
+	def, ok := LookupClass(className)
+	if !ok {
+		return Class{}, errors.New("Could not parse class type " + className)
+	}
+	return rollClass(def, playerName, sharedDice{}), nil
+}
+
+// rollClass rolls the initial values of a character of class def with dice d
+func rollClass(def ClassDef, playerName string, d Dice) Class {
+	c := Class{
+		PlayerName: playerName,
+		ClassName:  def.Name,
+		Health:     rollInt(def.Health, d),
+		Armor:      rollInt(def.Armor, d),
+		Strength:   rollAttribute(def.Strength, d),
+		Dexterity:  rollAttribute(def.Dexterity, d),
+		Intellect:  rollAttribute(def.Intellect, d),
+	}
+	c.Stamina = rollInt(def.Stamina, d)
+	c.MaxStamina = c.Stamina
+	c.MaxHealth, c.MaxArmor = c.Health, c.Armor
+	c.Level = 1
+	return c
+}
+
+// rollInt rolls a whole number in range r
+func rollInt(r StatRange, d Dice) int {
+	min, max := int(r.Min+.5), int(r.Max+.5)
+	return d.Intn(max-min+1) + min
+}
+
+// rollAttribute rolls an attribute in range r in steps of .05
+func rollAttribute(r StatRange, d Dice) float32 {
+	min, max := int(r.Min*20+.5), int(r.Max*20+.5)
+	return float32(d.Intn(max-min+1)+min) / 20
+}
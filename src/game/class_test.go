@@ -0,0 +1,185 @@
+package game
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestShippedClasses checks that the shipped class file holds the default
+// classes
+func TestShippedClasses(t *testing.T) {
+	defer func() { classes = DefaultClasses() }()
+
+	err := LoadClasses("../" + CLASSES_FILE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(classes, DefaultClasses()) {
+		t.Errorf("Got classes %v, expected the default classes", classes)
+	}
+}
+
+// TestLoadClassesInvalid checks that invalid class files are rejected and
+// leave the registry unchanged
+func TestLoadClassesInvalid(t *testing.T) {
+	defer func() { classes = DefaultClasses() }()
+
+	cases := map[string]string{
+		"empty":     `[]`,
+		"no name":   `[{"Health": {"Min": 80, "Max": 100}}]`,
+		"twice":     `[{"Name": "A"}, {"Name": "A"}]`,
+		"min > max": `[{"Name": "A", "Health": {"Min": 90, "Max": 80}}]`,
+		"attribute": `[{"Name": "A", "Health": {"Min": 80, "Max": 100},` +
+			` "Strength": {"Min": 0, "Max": 2}, "Moves": ["Smite",` +
+			` "Mace Strike", "Holy Light", "Sanctuary", "Rebuke",` +
+			` "Prayer"]}]`,
+		"unknown move": `[{"Name": "A", "Health": {"Min": 80, "Max": 100},` +
+			` "Moves": ["Smite", "Mace Strike", "Holy Light", "Sanctuary",` +
+			` "Rebuke", "Pray"]}]`,
+		"wrong slot": `[{"Name": "A", "Health": {"Min": 80, "Max": 100},` +
+			` "Moves": ["Smite", "Mace Strike", "Holy Light", "Sanctuary",` +
+			` "Rebuke", "Fireball"]}]`,
+	}
+	for name, body := range cases {
+		f, err := ioutil.TempFile("", "classes")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(f.Name())
+		f.WriteString(body)
+		f.Close()
+
+		if LoadClasses(f.Name()) == nil {
+			t.Errorf("Loaded invalid class file %s", name)
+		}
+	}
+	if len(classes) != len(DefaultClasses()) {
+		t.Errorf("Got %d classes after invalid loads, expected %d",
+			len(classes), len(DefaultClasses()))
+	}
+}
+
+// TestStateClasses checks that the default classes keep the state layout of
+// existing tables and that added classes get states of their own
+func TestStateClasses(t *testing.T) {
+	defer func() { classes = DefaultClasses() }()
+
+	p := mustClass("Wizard", "p")
+	e := mustClass("Archer", "e")
+	p.Health, p.Armor, e.Health, e.Armor = 10, 0, 10, 0
+	if s := getState(&p, &e); s != 2<<10|1<<4 {
+		t.Errorf("Got state %x, expected %x", s, 2<<10|1<<4)
+	}
+
+	for i := 0; len(classes) < maxClasses; i++ {
+		def := classes[0]
+		def.Name += string(rune('A' + i))
+		classes = append(classes, def)
+	}
+	seen := make(map[uint32]bool)
+	for _, pd := range classes {
+		for _, ed := range classes {
+			p.ClassName, e.ClassName = pd.Name, ed.Name
+			seen[getState(&p, &e)] = true
+		}
+	}
+	if len(seen) != len(classes)*len(classes) {
+		t.Errorf("Got %d states for %d class pairs", len(seen),
+			len(classes)*len(classes))
+	}
+}
+
+// fixedDice always rolls f for floats and the highest value up to n for ints
+type fixedDice struct {
+	f float32
+	n int
+}
+
+func (d fixedDice) Intn(n int) int {
+	if d.n < n-1 {
+		return d.n
+	}
+	return n - 1
+}
+
+func (d fixedDice) Float32() float32 {
+	return d.f
+}
+
+// TestMinMaxTraits checks that minmax expects the damage and heals of a
+// class scaled by its traits
+func TestMinMaxTraits(t *testing.T) {
+	defer func() { classes = DefaultClasses() }()
+	def, _ := LookupClass("Cleric")
+	def.Name, def.Traits = "Plain Cleric", ClassTraits{}
+	classes = append(classes, def)
+
+	p := Class{Strength: .5, Dexterity: .5, Intellect: .5}
+	cleric := Class{ClassName: "Cleric", Strength: .5, Dexterity: .5,
+		Intellect: 1}
+	plain := cleric
+	plain.ClassName = "Plain Cleric"
+
+	dc, dp := minMaxDamage(&p, &cleric), minMaxDamage(&p, &plain)
+	if d := dc[QUICK] - .75*dp[QUICK]; d > .001 || d < -.001 {
+		t.Errorf("Got quick damage %.2f, expected .75 of %.2f", dc[QUICK],
+			dp[QUICK])
+	}
+	hc, hp := minMaxHealth(&p, &cleric), minMaxHealth(&p, &plain)
+	if d := hc[EVADE] - 2*hp[EVADE]; d > .001 || d < -.001 {
+		t.Errorf("Got evade heal %.2f, expected twice %.2f", hc[EVADE],
+			hp[EVADE])
+	}
+}
+
+// TestTraits checks the signature mechanics of the rogue and cleric against
+// characters with the same stats and no traits
+func TestTraits(t *testing.T) {
+	base := Class{Health: 50, Armor: 20, Strength: .5, Dexterity: .5,
+		Intellect: .5}
+	as := func(className string) Class {
+		c := base
+		c.ClassName = className
+		return c
+	}
+
+	// a successful quick attack against a failed block, the backstab of the
+	// rogue rolls 9 damage and the quick fire of the archer 8
+	hit := fixedDice{.9, 20}
+	rogue, archer := as("Rogue"), as("Archer")
+	e1, e2 := as("Knight"), as("Knight")
+	resolveTurn(&rogue, &e1, QUICK, BLOCK, hit)
+	resolveTurn(&archer, &e2, QUICK, BLOCK, hit)
+	if e1.Armor != 20 || e1.Health != 41 {
+		t.Errorf("Got %d health %d armor after a rogue quick attack,"+
+			" expected 41 health 20 armor", e1.Health, e1.Armor)
+	}
+	if e2.Armor != 12 || e2.Health != 50 {
+		t.Errorf("Got %d health %d armor after an archer quick attack,"+
+			" expected 50 health 12 armor", e2.Health, e2.Armor)
+	}
+
+	cleric, knight := as("Cleric"), as("Knight")
+	e1, e2 = as("Knight"), as("Knight")
+	resolveTurn(&cleric, &e1, HEAVY, BLOCK, hit)
+	resolveTurn(&knight, &e2, HEAVY, BLOCK, hit)
+	if e1.Armor != 12 || e2.Armor != 9 {
+		t.Errorf("Got %d and %d armor after cleric and knight heavy"+
+			" attacks, expected 12 and 9", e1.Armor, e2.Armor)
+	}
+
+	// a successful evade against a quick attack, the prayer of the cleric
+	// rolls 7 and the heal of the wizard 6
+	heal := fixedDice{.1, 20}
+	cleric, wizard := as("Cleric"), as("Wizard")
+	e1, e2 = as("Knight"), as("Knight")
+	e1.Strength, e2.Strength = 0, 0
+	resolveTurn(&e1, &cleric, QUICK, EVADE, heal)
+	resolveTurn(&e2, &wizard, QUICK, EVADE, heal)
+	if cleric.Health != 64 || wizard.Health != 56 {
+		t.Errorf("Got %d and %d health after cleric and wizard evades,"+
+			" expected 64 and 56", cleric.Health, wizard.Health)
+	}
+}
@@ -0,0 +1,137 @@
+package game
+
+import "errors"
+
+// CombatModel shapes the damage rolls of attacks. Crit chance grows with the
+// dexterity of the attacker and crit damage with the damage attribute of the
+// attack, glancing blows are hits whose success roll barely beat the
+// defender and armor penetration lets part of the damage past armor
+type CombatModel struct {
+	Name         string
+	Crit         float32 // crit chance per point of attacker dexterity
+	CritDamage   float32 // crits deal 1 + this * damage attribute times more
+	Glance       float32 // hits beating the success roll by less are glancing
+	GlanceDamage float32 // scales the damage of glancing blows
+	Penetration  float32 // share of damage skipping armor per damage point
+	Variance     float32 // 1 keeps the uniform damage roll, 0 its average
+}
+
+// CombatModels are the combat models selectable by name
+var CombatModels = map[string]CombatModel{
+	"classic":  {"classic", 0, 0, 0, 0, 0, 1},
+	"standard": {"standard", .2, 1, .1, .5, .25, .8},
+	"swingy":   {"swingy", .4, 1.5, .2, .5, .25, 1},
+}
+
+// COMBAT is set by main using cmd line flags and is the combat model of every
+// turn. The classic model rolls damage like the game always has
+var COMBAT = CombatModels["classic"]
+
+// ParseCombatModel returns the combat model named s
+func ParseCombatModel(s string) (CombatModel, error) {
+	m, ok := CombatModels[s]
+	if !ok {
+		return CombatModels["classic"],
+			errors.New("Could not parse combat model")
+	}
+	return m, nil
+}
+
+// hitKind is how an attack landed
+type hitKind int
+
+const (
+	NORMAL_HIT hitKind = iota
+	CRIT_HIT
+	GLANCING_HIT
+)
+
+// String describes the hit kind as appended to the damage it dealt
+func (k hitKind) String() string {
+	switch k {
+	case CRIT_HIT:
+		return " with a critical hit"
+	case GLANCING_HIT:
+		return " with a glancing blow"
+	}
+	return ""
+}
+
+// label names the hit kind in turn records
+func (k hitKind) label() string {
+	switch k {
+	case CRIT_HIT:
+		return "crit"
+	case GLANCING_HIT:
+		return "glancing"
+	}
+	return "hit"
+}
+
+// critChance returns the chance a hit of c is a crit, at most Crit however
+// far gear raises the dexterity of c
+func (m CombatModel) critChance(c *Class) float32 {
+	return m.Crit * attribute(c, "Dexterity")
+}
+
+// critMult returns the damage multiplier of crits of c with attack def
+func (m CombatModel) critMult(c *Class, def MoveDef) float32 {
+	return 1 + m.CritDamage*attribute(c, def.Damage)
+}
+
+// spread pulls damage roll n of an attack with base roll base towards its
+// average by the variance of the model
+func (m CombatModel) spread(n, base int) float32 {
+	avg := float32(base) / 2
+	return avg + (float32(n)-avg)*m.Variance
+}
+
+// penetration returns how much of damage n of c with attack def skips armor
+func (m CombatModel) penetration(c *Class, def MoveDef, n int) int {
+	if def.Outcome != ATTACK {
+		return 0
+	}
+	return int(float32(n)*m.Penetration*attribute(c, def.Damage) + .5)
+}
+
+// rollAttack rolls the damage of c attacking e with def rolling with dice d,
+// returning 0 on a miss, along with how the attack landed
+func (m CombatModel) rollAttack(c, e *Class, def MoveDef, d Dice) (int,
+	hitKind) {
+	// higher enemy success attribute -> lower chance to hit
+	r, s := d.Float32(), attribute(e, def.Success)
+	if r <= s {
+		return 0, NORMAL_HIT
+	}
+
+	// higher attacker damage attribute -> more damage
+	n := m.spread(d.Intn(def.Base+1), def.Base)*attribute(c, def.Damage) +
+		1.5
+	kind := NORMAL_HIT
+	if r-s < m.Glance {
+		kind = GLANCING_HIT
+		n *= m.GlanceDamage
+	} else if m.Crit > 0 && d.Float32() < m.critChance(c) {
+		kind = CRIT_HIT
+		n *= m.critMult(c, def)
+	}
+	return int(n), kind
+}
+
+// damageMult returns the average damage multiplier of crits and glancing
+// blows on the hits of c with attack def against a success attribute s
+func (m CombatModel) damageMult(c *Class, def MoveDef, s float32) float32 {
+	var glance float32
+	if s < 1 {
+		glance = m.Glance / (1 - s)
+		if glance > 1 {
+			glance = 1
+		}
+	}
+	crit := m.critChance(c)
+	if crit > 1 {
+		crit = 1
+	}
+	return glance*m.GlanceDamage +
+		(1-glance)*(1+crit*(m.critMult(c, def)-1))
+}
@@ -0,0 +1,156 @@
+package game
+
+import "testing"
+
+// seqDice rolls its floats in order, repeating the last, and the highest
+// value up to n for ints
+type seqDice struct {
+	floats []float32
+	n      int
+}
+
+func (d *seqDice) Intn(n int) int {
+	return fixedDice{0, d.n}.Intn(n)
+}
+
+func (d *seqDice) Float32() float32 {
+	f := d.floats[0]
+	if len(d.floats) > 1 {
+		d.floats = d.floats[1:]
+	}
+	return f
+}
+
+// TestCombatRolls checks the crits, glancing blows and variance of the
+// standard combat model
+func TestCombatRolls(t *testing.T) {
+	defer func() { COMBAT = CombatModels["classic"] }()
+	COMBAT = CombatModels["standard"]
+
+	c := Class{Strength: .5, Dexterity: 1, Intellect: .5}
+	e := Class{Strength: .5, Dexterity: .5, Intellect: .5}
+	heavy := baseMoves[HEAVY]
+
+	// the roll of 20 is pulled to 18 by the variance, 18*.5+1.5 = 10.5
+	cases := []struct {
+		name   string
+		floats []float32
+		damage int
+		kind   hitKind
+	}{
+		{"miss", []float32{.5}, 0, NORMAL_HIT},
+		{"hit", []float32{.9, .5}, 10, NORMAL_HIT},
+		{"crit", []float32{.9, .1}, 15, CRIT_HIT},
+		{"glance", []float32{.55}, 5, GLANCING_HIT},
+	}
+	for _, tc := range cases {
+		n, kind := COMBAT.rollAttack(&c, &e, heavy,
+			&seqDice{tc.floats, 20})
+		if n != tc.damage || kind != tc.kind {
+			t.Errorf("%s: got %d damage%s, expected %d damage%s", tc.name,
+				n, kind, tc.damage, tc.kind)
+		}
+	}
+}
+
+// TestTurnHits checks that turns report how the attacks of both characters
+// landed and the damage they dealt
+func TestTurnHits(t *testing.T) {
+	defer func() { COMBAT = CombatModels["classic"] }()
+	COMBAT = CombatModels["standard"]
+
+	cases := []struct {
+		name   string
+		m2     Move
+		floats []float32
+		hits   TurnHits
+	}{
+		{"exchange", QUICK, []float32{.9, .1, .55},
+			TurnHits{"crit", "glancing", 15, 5}},
+		{"blocked", BLOCK, []float32{.9, .9, .1}, TurnHits{}},
+	}
+	for _, tc := range cases {
+		p := Class{Health: 100, Strength: .5, Dexterity: 1, Intellect: .5}
+		e := Class{Health: 100, Strength: .5, Dexterity: .5, Intellect: .5}
+
+		_, _, hits := playTurn(&p, &e, HEAVY, tc.m2, nil,
+			&seqDice{tc.floats, 20})
+		if hits != tc.hits {
+			t.Errorf("%s: got hits %+v, expected %+v", tc.name, hits,
+				tc.hits)
+		}
+	}
+}
+
+// TestPenetration checks that part of the damage of an attack skips armor
+// and that the classic model lets none through
+func TestPenetration(t *testing.T) {
+	defer func() { COMBAT = CombatModels["classic"] }()
+
+	for _, tc := range []struct {
+		model  string
+		health int
+		armor  int
+	}{
+		{"classic", 50, 9},
+		{"swingy", 49, 10},
+	} {
+		COMBAT = CombatModels[tc.model]
+		p := Class{Health: 50, Strength: .5, Dexterity: .5, Intellect: .5}
+		e := p
+		e.Armor = 20
+
+		// the roll of 20 deals 11 damage, .25*.5 of it penetrating
+		resolveTurn(&p, &e, HEAVY, BLOCK, &seqDice{[]float32{.9, .9}, 20})
+		if e.Health != tc.health || e.Armor != tc.armor {
+			t.Errorf("%s: got %d health %d armor, expected %d health %d"+
+				" armor", tc.model, e.Health, e.Armor, tc.health, tc.armor)
+		}
+	}
+}
+
+// TestPenetrationShielded checks that shields absorb damage before any of it
+// penetrates armor
+func TestPenetrationShielded(t *testing.T) {
+	defer func() { COMBAT = CombatModels["classic"] }()
+	COMBAT = CombatModels["swingy"]
+
+	p := Class{Health: 50, Strength: .5, Dexterity: .5, Intellect: .5}
+	e := p
+	e.Armor = 20
+	e.Effects[SHIELDED] = Effect{Turns: 2, Power: 15}
+
+	// the 11 damage of the roll of 20 all go into the shield
+	resolveTurn(&p, &e, HEAVY, BLOCK, &seqDice{[]float32{.9, .9}, 20})
+	if e.Health != 50 || e.Armor != 20 || e.Effects[SHIELDED].Power != 4 {
+		t.Errorf("Got %d health %d armor %d shield, expected 50, 20 and 4",
+			e.Health, e.Armor, e.Effects[SHIELDED].Power)
+	}
+}
+
+// TestCritChance checks that gear raising dexterity above 1 doesn't raise
+// the crit chance above that of the model
+func TestCritChance(t *testing.T) {
+	m := CombatModels["swingy"]
+	c := Class{Dexterity: 1.1}
+	if p := m.critChance(&c); p != m.Crit {
+		t.Errorf("Got crit chance %.2f, expected %.2f", p, m.Crit)
+	}
+}
+
+// TestDamageMult checks that minmax expects no change from the classic model
+// and more damage from dexterous attackers under the swingy one
+func TestDamageMult(t *testing.T) {
+	c := Class{Strength: 1, Dexterity: 1, Intellect: 1}
+	heavy := baseMoves[HEAVY]
+
+	if m := CombatModels["classic"].damageMult(&c, heavy, .5); m != 1 {
+		t.Errorf("Got classic damage multiplier %f, expected 1", m)
+	}
+	// a fifth of hits glance for half damage and the rest crit 40% of the
+	// time for 2.5 times the damage, .2*.5 + .8*(1+.4*1.5) = 1.38
+	m := CombatModels["swingy"].damageMult(&c, heavy, 0)
+	if m < 1.37 || m > 1.39 {
+		t.Errorf("Got swingy damage multiplier %f, expected 1.38", m)
+	}
+}
@@ -0,0 +1,133 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// curriculum stages in the order they are trained
+const (
+	STAGE_RAND   = "rand"
+	STAGE_MINMAX = "minmax"
+	STAGE_LEAGUE = "league"
+)
+
+var stages = []string{STAGE_RAND, STAGE_MINMAX, STAGE_LEAGUE}
+
+// Snapshotter is implemented by learners which can be frozen into an agent
+// that plays greedily with a copy of what they have learned so far
+type Snapshotter interface {
+	Learner
+	Snapshot() Agent
+}
+
+// CurriculumConfig configures curriculum training
+type CurriculumConfig struct {
+	Threshold   float32 // win rate against a stage needed to advance
+	EvalEvery   int     // matches between evaluations
+	EvalMatches int     // matches played for each evaluation
+	LeagueSize  int     // most snapshots kept in the league pool
+}
+
+// CurriculumReport describes the evaluation made after a block of training
+type CurriculumReport struct {
+	Episode  int
+	Stage    string // stage that was trained and evaluated
+	WinRate  float32
+	Advanced bool // whether the win rate moved training to the next stage
+}
+
+// Snapshot returns a greedy agent using a copy of the current tables
+func (a *Reinforcement) Snapshot() Agent {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	frozen := &Reinforcement{QT: a.QT.Copy()}
+	if a.QT2 != nil {
+		frozen.QT2 = a.QT2.Copy()
+	}
+	return Greedy{Scorer: frozen}
+}
+
+// Snapshot returns a greedy agent using a copy of the current network
+func (a *DQN) Snapshot() Agent {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return Greedy{Scorer: &DQN{Net: a.Net.Copy()}}
+}
+
+// league is a pool of frozen snapshots of the learner
+type league struct {
+	pool []Agent
+	size int
+}
+
+// add puts a snapshot in the pool, dropping the oldest once full
+func (lg *league) add(a Agent) {
+	lg.pool = append(lg.pool, a)
+	if len(lg.pool) > lg.size {
+		lg.pool = lg.pool[1:]
+	}
+}
+
+// opponent returns a random snapshot of the pool
+func (lg *league) opponent() Agent {
+	return lg.pool[rand.Intn(len(lg.pool))]
+}
+
+// evaluate returns the win rate of a against the whole pool
+func (lg *league) evaluate(a Agent, matches int) float32 {
+	var wins float32
+	for i := 0; i < matches; i++ {
+		if PlayMatch(frozenAgent{a}, lg.opponent(), "") == 1 {
+			wins++
+		}
+	}
+	return wins / float32(matches)
+}
+
+// TrainCurriculum trains l for episodes matches, first against rand, then
+// against minmax and finally against a league of frozen snapshots of itself.
+// Training moves to the next stage once the win rate against the current one
+// reaches the threshold. In the league stage a new snapshot joins the pool
+// every time l reaches the threshold against it. report is called after
+// every evaluation
+func TrainCurriculum(l Snapshotter, episodes int, cfg CurriculumConfig,
+	report func(CurriculumReport)) {
+	stage := 0
+	lg := &league{size: cfg.LeagueSize}
+
+	for done := 0; done < episodes; done += cfg.EvalEvery {
+		n := cfg.EvalEvery
+		if done+n > episodes {
+			n = episodes - done
+		}
+
+		r := CurriculumReport{Episode: done + n, Stage: stages[stage]}
+		switch stages[stage] {
+		case STAGE_RAND:
+			SelfPlay(l, randAgent{}, n)
+			r.WinRate = Evaluate(l.Snapshot(), randAgent{}, cfg.EvalMatches)
+		case STAGE_MINMAX:
+			SelfPlay(l, minMaxAgent{}, n)
+			r.WinRate = Evaluate(l.Snapshot(), minMaxAgent{}, cfg.EvalMatches)
+		case STAGE_LEAGUE:
+			for i := 0; i < n; i++ {
+				PlayMatch(l, lg.opponent(), fmt.Sprintf("league%d", i))
+			}
+			r.WinRate = lg.evaluate(l.Snapshot(), cfg.EvalMatches)
+		}
+
+		if r.WinRate >= cfg.Threshold {
+			r.Advanced = stage < len(stages)-1
+			if r.Advanced {
+				stage++
+			}
+			if stages[stage] == STAGE_LEAGUE {
+				lg.add(l.Snapshot())
+			}
+		}
+		report(r)
+	}
+}
@@ -0,0 +1,43 @@
+package game
+
+import "testing"
+
+// TestCurriculumStages checks that stages advance in order once the
+// threshold is reached and that the league stage is never left
+func TestCurriculumStages(t *testing.T) {
+	a := NewReinforcement(make(QTable), QLearning{})
+	a.Metrics = nil
+
+	var reports []CurriculumReport
+	cfg := CurriculumConfig{Threshold: 0, EvalEvery: 5, EvalMatches: 5,
+		LeagueSize: 2}
+	TrainCurriculum(a, 25, cfg, func(r CurriculumReport) {
+		reports = append(reports, r)
+	})
+
+	expected := []string{STAGE_RAND, STAGE_MINMAX, STAGE_LEAGUE, STAGE_LEAGUE,
+		STAGE_LEAGUE}
+	if len(reports) != len(expected) {
+		t.Fatalf("Got %d reports, expected %d", len(reports), len(expected))
+	}
+	for i, r := range reports {
+		if r.Stage != expected[i] {
+			t.Errorf("Report %d in stage %s, expected %s", i, r.Stage,
+				expected[i])
+		}
+		if r.Advanced != (i < 2) {
+			t.Errorf("Report %d advanced %t", i, r.Advanced)
+		}
+	}
+}
+
+// TestLeaguePool checks that the oldest snapshots leave a full pool
+func TestLeaguePool(t *testing.T) {
+	lg := &league{size: 2}
+	for m := HEAVY; m <= STANDARD; m++ {
+		lg.add(fixedAgent{m})
+	}
+	if len(lg.pool) != 2 || lg.pool[0] != (fixedAgent{QUICK}) {
+		t.Errorf("Got pool %v, expected the last 2 snapshots", lg.pool)
+	}
+}
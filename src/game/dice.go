@@ -0,0 +1,27 @@
+package game
+
+import "math/rand"
+
+// Dice is the source of randomness of the game rules and exploration.
+// Parallel trainers give every worker its own *rand.Rand so they do not
+// contend on the lock of the shared generator
+type Dice interface {
+	Intn(n int) int
+	Float32() float32
+}
+
+// sharedDice rolls with the shared generator of math/rand
+type sharedDice struct{}
+
+func (sharedDice) Intn(n int) int {
+	return rand.Intn(n)
+}
+
+func (sharedDice) Float32() float32 {
+	return rand.Float32()
+}
+
+// newDice returns a generator seeded from the shared one
+func newDice() Dice {
+	return rand.New(rand.NewSource(rand.Int63()))
+}
@@ -0,0 +1,238 @@
+package game
+
+import (
+	"encoding/gob"
+	"errors"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+)
+
+type Difficulty int
+
+const (
+	// DIFF_HARD is the zero value so characters saved before difficulties
+	// existed keep playing against the unmodified agent
+	DIFF_HARD Difficulty = iota
+	DIFF_NORMAL
+	DIFF_EASY
+	DIFF_ADAPTIVE
+)
+
+// skill levels for the fixed tiers, a skill of 1 plays the agent unmodified
+// and 0 plays with the most randomness and mistakes
+const (
+	skillEasy   float32 = 0
+	skillNormal float32 = .5
+)
+
+// maximum chance of random moves and of deliberately bad moves at skill 0
+const (
+	maxRandomRate  float32 = .5
+	maxMistakeRate float32 = .25
+)
+
+// adaptStep controls how fast adaptive skill reacts to the player win rate
+const adaptStep float32 = .5
+
+// TargetWinRate is the player win rate the adaptive difficulty aims for. Set
+// by main using cmd line flags
+var TargetWinRate float32 = .5
+
+var adaptiveStats map[string]*winRecord
+var adaptiveLock sync.Mutex
+
+// winRecord tracks the results of one player against the AI
+type winRecord struct {
+	Wins    int
+	Losses  int
+	WinRate float32 // moving average of player wins
+	Skill   float32
+}
+
+// ParseDifficulty converts a difficulty name into a Difficulty
+func ParseDifficulty(s string) (Difficulty, error) {
+	switch strings.ToLower(s) {
+	case "easy":
+		return DIFF_EASY, nil
+	case "normal":
+		return DIFF_NORMAL, nil
+	case "hard", "":
+		return DIFF_HARD, nil
+	case "adaptive":
+		return DIFF_ADAPTIVE, nil
+	}
+	return DIFF_HARD, errors.New("Could not parse difficulty")
+}
+
+func (d Difficulty) String() string {
+	switch d {
+	case DIFF_EASY:
+		return "Easy"
+	case DIFF_NORMAL:
+		return "Normal"
+	case DIFF_ADAPTIVE:
+		return "Adaptive"
+	default:
+		return "Hard"
+	}
+}
+
+// difficultyAgent wraps an agent and weakens it by playing random moves and
+// deliberate mistakes, the lower the skill the more often it does so
+type difficultyAgent struct {
+	base  Agent
+	skill float32
+}
+
+// WithDifficulty wraps agent a so that it plays at difficulty d against the
+// player named player
+func WithDifficulty(a Agent, d Difficulty, player string) Agent {
+	switch d {
+	case DIFF_EASY:
+		return difficultyAgent{a, skillEasy}
+	case DIFF_NORMAL:
+		return difficultyAgent{a, skillNormal}
+	case DIFF_ADAPTIVE:
+		return difficultyAgent{a, adaptiveSkill(player)}
+	default:
+		return a
+	}
+}
+
+func (d difficultyAgent) GetTurn(p, e *Class) Move {
+	m, _ := d.turn(p, e)
+	return m
+}
+
+// turn gets the move like GetTurn and whether it is a random move or mistake
+// rather than the choice of the base agent
+func (d difficultyAgent) turn(p, e *Class) (Move, bool) {
+	randomRate := maxRandomRate * (1 - d.skill)
+	mistakeRate := maxMistakeRate * (1 - d.skill)
+
+	r := rand.Float32()
+	if r < randomRate {
+		return getTurnRand(), true
+	}
+	if r < randomRate+mistakeRate {
+		// pick the move the agent itself thinks is worst
+		if s, ok := d.base.(Scorer); ok {
+			return worstMove(s.Scores(p, e)), true
+		}
+		return getTurnRand(), true
+	}
+	return d.base.GetTurn(p, e), false
+}
+
+// difficultyTurn gets the move of agent a against p with e and whether a
+// difficulty wrapping a replaced the choice of the agent it wraps
+func difficultyTurn(a Agent, p, e *Class) (Move, bool) {
+	if d, ok := a.(difficultyAgent); ok {
+		return d.turn(p, e)
+	}
+	return a.GetTurn(p, e), false
+}
+
+func (d difficultyAgent) Scores(p, e *Class) []float32 {
+	if s, ok := d.base.(Scorer); ok {
+		return s.Scores(p, e)
+	}
+	return nil
+}
+
+// worstMove returns the move with the lowest score
+func worstMove(scores []float32) Move {
+	var m Move
+	for i, v := range scores {
+		if v < scores[m] {
+			m = Move(i)
+		}
+	}
+	return m
+}
+
+// update records the result of a match and moves skill so the players win
+// rate approaches TargetWinRate
+func (r *winRecord) update(playerWon bool) {
+	var won float32
+	if playerWon {
+		r.Wins++
+		won = 1
+	} else {
+		r.Losses++
+	}
+	r.WinRate = .8*r.WinRate + .2*won
+
+	r.Skill += adaptStep * (r.WinRate - TargetWinRate)
+	if r.Skill < 0 {
+		r.Skill = 0
+	} else if r.Skill > 1 {
+		r.Skill = 1
+	}
+}
+
+// adaptiveSkill returns the current adaptive skill used against player
+func adaptiveSkill(player string) float32 {
+	adaptiveLock.Lock()
+	defer adaptiveLock.Unlock()
+
+	return getWinRecord(player).Skill
+}
+
+// RecordResult updates the adaptive difficulty of player after a match
+func RecordResult(player string, playerWon bool) {
+	adaptiveLock.Lock()
+	defer adaptiveLock.Unlock()
+
+	getWinRecord(player).update(playerWon)
+	saveAdaptive()
+}
+
+// getWinRecord returns the record of player, creating one if needed. Only
+// call with adaptiveLock held
+func getWinRecord(player string) *winRecord {
+	if adaptiveStats == nil {
+		loadAdaptive()
+	}
+	r, ok := adaptiveStats[player]
+	if !ok {
+		r = &winRecord{WinRate: TargetWinRate, Skill: skillNormal}
+		adaptiveStats[player] = r
+	}
+	return r
+}
+
+// loadAdaptive loads adaptive stats from file if it exists. Only call with
+// adaptiveLock held
+func loadAdaptive() {
+	adaptiveStats = make(map[string]*winRecord)
+
+	f, err := os.Open("adaptive")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(f)
+	err = dec.Decode(&adaptiveStats)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// saveAdaptive saves adaptive stats to disk. Only call with adaptiveLock held
+func saveAdaptive() {
+	f, err := os.Create("adaptive")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	enc := gob.NewEncoder(f)
+	err = enc.Encode(adaptiveStats)
+	if err != nil {
+		panic(err)
+	}
+}
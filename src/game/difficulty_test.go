@@ -0,0 +1,43 @@
+package game
+
+import "testing"
+
+// TestAdaptiveSkill checks that adaptive skill rises while the player keeps
+// winning and falls while the player keeps losing
+func TestAdaptiveSkill(t *testing.T) {
+	r := &winRecord{WinRate: TargetWinRate, Skill: skillNormal}
+	for i := 0; i < 10; i++ {
+		r.update(true)
+	}
+	if r.Skill <= skillNormal {
+		t.Errorf("Skill did not increase after player wins, got %f", r.Skill)
+	}
+	if r.Wins != 10 {
+		t.Errorf("Got %d wins, expected 10", r.Wins)
+	}
+
+	for i := 0; i < 20; i++ {
+		r.update(false)
+	}
+	if r.Skill != 0 {
+		t.Errorf("Skill did not bottom out after player losses, got %f", r.Skill)
+	}
+}
+
+// TestWithDifficulty checks that hard difficulty leaves the agent unmodified
+// and that the weakened agents only return valid moves
+func TestWithDifficulty(t *testing.T) {
+	if _, ok := WithDifficulty(minMaxAgent{}, DIFF_HARD, "").(minMaxAgent); !ok {
+		t.Errorf("Hard difficulty should not wrap the agent")
+	}
+
+	p := mustClass("Knight", "Knight")
+	e := mustClass("Wizard", "Wizard")
+	a := WithDifficulty(minMaxAgent{}, DIFF_EASY, "")
+	for i := 0; i < nClassTests; i++ {
+		m := a.GetTurn(&p, &e)
+		if m < HEAVY || m > EVADE {
+			t.Errorf("Got invalid move %d on test %d", m, i)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package game
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+)
+
+// nFeatures returns the number of inputs fed to the dqn network, which grows
+// with the number of classes in the registry
+func nFeatures() int {
+	return 2 * (7 + nEffects + len(classes))
+}
+
+// DQNLearningRate, HiddenSize, ReplaySize, BatchSize and SyncEvery are set by
+// main using cmd line flags and configure new dqn agents
+var DQNLearningRate float32 = .001
+var HiddenSize int = 32
+var ReplaySize int = 10000
+var BatchSize int = 32
+var SyncEvery int = 500
+
+// experience is one turn stored in the replay buffer of a dqn agent
+type experience struct {
+	state     []float32
+	action    Move
+	reward    float32
+	nextState []float32
+	done      bool
+}
+
+// DQN is an agent approximating the qtable with a small neural network fed
+// with the exact stats of both characters. It learns from a replay buffer of
+// past turns and bootstraps from a target network synced every SyncEvery
+// training steps
+type DQN struct {
+	Net          *Network
+	Target       *Network
+	LearningRate float32
+	Discount     float32
+	Explore      ExplorePolicy
+	BatchSize    int
+	SyncEvery    int
+	File         string     // file the network is saved to, empty to never save
+	Metrics      *Telemetry // training telemetry, nil to record none
+
+	replay []experience
+	next   int // replay index overwritten next once the buffer is full
+	steps  int
+	mu     sync.Mutex
+}
+
+var serverNet *DQN
+var serverNetLock sync.Mutex
+
+// features converts both characters into the inputs of the network, stats are
+// scaled to roughly between 0 and 1 and classes are one hot encoded
+func features(p, e *Class) []float32 {
+	x := make([]float32, 0, nFeatures())
+	for _, c := range []*Class{p, e} {
+		x = append(x,
+			float32(c.Health)/100,
+			float32(c.Armor)/20,
+			float32(c.Stamina)/100,
+			c.Strength,
+			c.Dexterity,
+			c.Intellect,
+			c.gear().Damage,
+		)
+		for k := BLEED; k < nEffects; k++ {
+			x = append(x, float32(c.Effects[k].Turns)/3)
+		}
+		onehot := make([]float32, len(classes))
+		if i := classIndex(c.ClassName); i >= 0 {
+			onehot[i] = 1
+		}
+		x = append(x, onehot...)
+	}
+	return x
+}
+
+// dqnReward is a continuous version of the default reward preset using the
+// exact change in health and armor, measured in tens of points
+func dqnReward(p, e, nextP, nextE *Class) float32 {
+	var reward float32
+	reward += 1.5 * float32(p.Health-nextP.Health) / 10
+	reward += 1.5 * float32(p.Armor-nextP.Armor) / 10
+	if d := float32(nextE.Health-e.Health) / 10; d > 0 {
+		reward += d
+	} else {
+		reward += .5 * d
+	}
+	if d := float32(nextE.Armor-e.Armor) / 10; d > 0 {
+		reward += d
+	} else {
+		reward += .5 * d
+	}
+	return reward
+}
+
+// NewDQN creates a dqn agent with a newly initialized network, hyperparameters
+// and exploration are taken from the values set by main
+func NewDQN() *DQN {
+	net := NewNetwork(nFeatures(), HiddenSize, HiddenSize, 6)
+	return &DQN{
+		Net:          net,
+		Target:       net.Copy(),
+		LearningRate: DQNLearningRate,
+		Discount:     Discount,
+		Explore:      NewExplorePolicy(),
+		BatchSize:    BatchSize,
+		SyncEvery:    SyncEvery,
+		Metrics:      METRICS,
+		replay:       make([]experience, 0, ReplaySize),
+	}
+}
+
+// LoadDQN creates a dqn agent from the network saved in fn, starting from a
+// new network if fn does not exist. The agent saves back to fn
+func LoadDQN(fn string) (*DQN, error) {
+	a := NewDQN()
+	a.File = fn
+
+	f, err := os.Open(fn)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return a, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	net := &Network{}
+	dec := gob.NewDecoder(f)
+	err = dec.Decode(net)
+	if err != nil {
+		return nil, err
+	}
+	if len(net.Layers) == 0 || net.Layers[0].In != nFeatures() {
+		return nil, fmt.Errorf("Network in %s was trained with a different"+
+			" number of classes", fn)
+	}
+	a.Net = net
+	a.Target = net.Copy()
+
+	return a, nil
+}
+
+// serverDQN returns the dqn agent used by the server, loading its network on
+// first use
+func serverDQN() *DQN {
+	serverNetLock.Lock()
+	defer serverNetLock.Unlock()
+
+	if serverNet == nil {
+		a, err := LoadDQN("dqn")
+		if err != nil {
+			panic(err)
+		}
+		serverNet = a
+		fmt.Println("DQN initialized")
+	}
+	return serverNet
+}
+
+// GetTurn selects a move from the network outputs using the exploration
+// policy
+func (a *DQN) GetTurn(p, e *Class) Move {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.Explore.Select(sharedDice{}, getState(p, e),
+		a.Net.Predict(features(p, e)))
+}
+
+// Scores returns the network outputs for every move
+func (a *DQN) Scores(p, e *Class) []float32 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.Net.Predict(features(p, e))
+}
+
+// Observe stores the turn in the replay buffer and trains the network on a
+// batch sampled from the buffer
+func (a *DQN) Observe(
+	key string, p, e *Class, m Move, nextP, nextE *Class, done bool,
+) {
+	exp := experience{
+		state:     features(p, e),
+		action:    m,
+		reward:    dqnReward(p, e, nextP, nextE),
+		nextState: features(nextP, nextE),
+		done:      done,
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.Metrics.reward(key, exp.reward)
+	if len(a.replay) < cap(a.replay) {
+		a.replay = append(a.replay, exp)
+	} else {
+		a.replay[a.next] = exp
+		a.next = (a.next + 1) % len(a.replay)
+	}
+
+	if len(a.replay) >= a.BatchSize {
+		a.trainBatch()
+	}
+	if done {
+		a.Explore.EndEpisode()
+		a.Metrics.endEpisode(key, a.Explore, 0)
+	}
+}
+
+// trainBatch takes one gradient step on a batch sampled from the replay
+// buffer. Only call with a.mu held
+func (a *DQN) trainBatch() {
+	g := newGradients(a.Net)
+	for i := 0; i < a.BatchSize; i++ {
+		exp := a.replay[rand.Intn(len(a.replay))]
+
+		target := exp.reward
+		if !exp.done {
+			next := a.Target.Predict(exp.nextState)
+			target += a.Discount * next[bestMove(next)]
+		}
+
+		// only the move played has an error, clipped like a huber loss
+		outErr := make([]float32, 6)
+		outErr[exp.action] = a.Net.Predict(exp.state)[exp.action] - target
+		a.Metrics.tdError(outErr[exp.action])
+		if outErr[exp.action] > 1 {
+			outErr[exp.action] = 1
+		} else if outErr[exp.action] < -1 {
+			outErr[exp.action] = -1
+		}
+		a.Net.backward(exp.state, outErr, g)
+	}
+	a.Net.apply(g, a.LearningRate/float32(a.BatchSize))
+
+	a.steps++
+	if a.steps%a.SyncEvery == 0 {
+		a.Target = a.Net.Copy()
+	}
+}
+
+// Save writes the network to the file of the agent
+func (a *DQN) Save() error {
+	if a.File == "" {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.Create(a.File)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := gob.NewEncoder(f)
+	return enc.Encode(a.Net)
+}
@@ -0,0 +1,121 @@
+package game
+
+import (
+	"encoding/gob"
+	"os"
+)
+
+// PlanningSteps is set by main using cmd line flags and is the number of
+// simulated updates new reinforcement agents make per real turn
+var PlanningSteps int
+
+// outcome is a state reached after a move together with whether the match
+// ended there
+type outcome struct {
+	NextState uint32
+	Done      bool
+}
+
+// Model is a learned transition model counting the outcomes observed after
+// playing each move in each state
+type Model struct {
+	Counts map[StateAction]map[outcome]int
+	keys   []StateAction
+}
+
+// NewModel returns an empty transition model
+func NewModel() *Model {
+	return &Model{Counts: make(map[StateAction]map[outcome]int)}
+}
+
+// observe adds transition t to the model
+func (m *Model) observe(t Transition) {
+	sa := StateAction{t.State, t.Action}
+	outcomes, ok := m.Counts[sa]
+	if !ok {
+		outcomes = make(map[outcome]int)
+		m.Counts[sa] = outcomes
+		m.keys = append(m.keys, sa)
+	}
+	outcomes[outcome{t.NextState, t.Done}]++
+}
+
+// sample returns a simulated transition from a random previously observed
+// state and move, with the outcome drawn by how often it was observed and
+// rewarded by preset reward, rolling with dice d
+func (m *Model) sample(reward RewardPreset, d Dice) Transition {
+	if len(m.keys) != len(m.Counts) {
+		m.keys = m.keys[:0]
+		for sa := range m.Counts {
+			m.keys = append(m.keys, sa)
+		}
+	}
+
+	sa := m.keys[d.Intn(len(m.keys))]
+	outcomes := m.Counts[sa]
+
+	var total int
+	for _, n := range outcomes {
+		total += n
+	}
+	r := d.Intn(total)
+
+	var o outcome
+	for o = range outcomes {
+		r -= outcomes[o]
+		if r < 0 {
+			break
+		}
+	}
+
+	return Transition{
+		State:     sa.State,
+		Action:    sa.Action,
+		Reward:    reward.reward(sa.State, o.NextState),
+		NextState: o.NextState,
+		Done:      o.Done,
+	}
+}
+
+// Save writes the model to file fn
+func (m *Model) Save(fn string) error {
+	f, err := os.Create(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := gob.NewEncoder(f)
+	return enc.Encode(m)
+}
+
+// LoadModel reads a model from file fn
+func LoadModel(fn string) (*Model, error) {
+	m := NewModel()
+
+	f, err := os.Open(fn)
+	if err != nil {
+		return m, err
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(f)
+	err = dec.Decode(m)
+	return m, err
+}
+
+// plan records transition t in the model and makes a.Planning simulated
+// q-learning updates from the model. Only call with a.mu held
+func (a *Reinforcement) plan(t Transition) {
+	if a.Planning <= 0 {
+		return
+	}
+	if a.Model == nil {
+		a.Model = NewModel()
+	}
+	a.Model.observe(t)
+
+	for i := 0; i < a.Planning; i++ {
+		QLearning{}.Update(a, nil, a.Model.sample(a.Reward, a.dice()))
+	}
+}
@@ -0,0 +1,41 @@
+package game
+
+import "testing"
+
+// TestModelSample checks that the model only simulates observed transitions
+func TestModelSample(t *testing.T) {
+	m := NewModel()
+	m.observe(Transition{State: 1, Action: BLOCK, NextState: 2})
+	m.observe(Transition{State: 1, Action: BLOCK, NextState: 3, Done: true})
+
+	for i := 0; i < nClassTests; i++ {
+		tr := m.sample(REWARD, sharedDice{})
+		if tr.State != 1 || tr.Action != BLOCK {
+			t.Errorf("Sampled unobserved state %d and move %d",
+				tr.State, tr.Action)
+		}
+		if tr.NextState == 2 && tr.Done || tr.NextState == 3 && !tr.Done {
+			t.Errorf("Sampled wrong outcome %d done %t",
+				tr.NextState, tr.Done)
+		}
+	}
+}
+
+// TestPlanning checks that planning keeps learning from the model
+func TestPlanning(t *testing.T) {
+	a := NewReinforcement(make(QTable), QLearning{})
+	a.LearningRate = .1
+	a.Planning = 10
+
+	// enemy loses health so the move is rewarded
+	tr := Transition{State: 0x0200, Action: HEAVY, NextState: 0x0100,
+		Done: true}
+	tr.Reward = a.Reward.reward(tr.State, tr.NextState)
+	a.update(&Episode{}, tr)
+
+	// one real update moves the value by 10% of the reward, planning more
+	if v := a.QT.get(tr.State)[HEAVY]; v <= a.LearningRate*tr.Reward {
+		t.Errorf("Got value %f, expected planning to exceed %f", v,
+			a.LearningRate*tr.Reward)
+	}
+}
@@ -0,0 +1,181 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EffectKind is a lasting status effect a character can be under
+type EffectKind int
+
+const (
+	BLEED    EffectKind = iota // loses health every turn, skipping armor
+	BURN                       // takes damage every turn and fails some moves
+	STUN                       // skips its turns
+	SHIELDED                   // a temporary shield absorbs damage first
+)
+
+// nEffects is the number of effect kinds
+const nEffects = 4
+
+// burnFailChance is the chance a burning character fails its move
+const burnFailChance = .25
+
+func (k EffectKind) String() string {
+	switch k {
+	case BLEED:
+		return "bleed"
+	case BURN:
+		return "burn"
+	case STUN:
+		return "stun"
+	case SHIELDED:
+		return "shielded"
+	}
+	return "unknown"
+}
+
+// ParseEffectKind parses the name of an effect kind as returned by String
+func ParseEffectKind(s string) (EffectKind, error) {
+	for k := BLEED; k < nEffects; k++ {
+		if k.String() == s {
+			return k, nil
+		}
+	}
+	return BLEED, errors.New("Could not parse effect " + s)
+}
+
+// Effect is the state of one kind of effect on a character
+type Effect struct {
+	Turns int // turns left including the current one, 0 when inactive
+	Power int // damage per turn of bleed and burn, points left of a shield
+}
+
+// EffectSpec is an effect a move applies when it succeeds. Shields are put on
+// the character making the move and every other effect on its opponent
+type EffectSpec struct {
+	Kind   string
+	Turns  int
+	Power  int
+	Chance float32 `json:",omitempty"` // chance of applying, 0 is always
+}
+
+// validate checks that the spec names an effect and lasts at least a turn
+func (s EffectSpec) validate() error {
+	k, err := ParseEffectKind(s.Kind)
+	if err != nil {
+		return err
+	}
+	if s.Turns < 1 || s.Power < 0 || s.Chance < 0 || s.Chance > 1 {
+		return fmt.Errorf("%s effect out of bounds", s.Kind)
+	}
+	if (k == BLEED || k == BURN || k == SHIELDED) && s.Power == 0 {
+		return fmt.Errorf("%s effect needs power", s.Kind)
+	}
+	return nil
+}
+
+// has returns whether c is under an effect of kind k
+func (c *Class) has(k EffectKind) bool {
+	return c.Effects[k].Turns > 0
+}
+
+// EffectsText describes the active effects of c and the turns they last
+func (c *Class) EffectsText() string {
+	var active []string
+	for k := BLEED; k < nEffects; k++ {
+		if c.has(k) {
+			active = append(active, fmt.Sprintf("%s (%d)", k,
+				c.Effects[k].Turns))
+		}
+	}
+	if len(active) == 0 {
+		return "none"
+	}
+	return strings.Join(active, ", ")
+}
+
+// tickEffects applies the damage over time c is under at the start of a turn
+// and returns a description of it
+func tickEffects(c *Class) string {
+	var res string
+	if c.has(BLEED) {
+		c.Health -= c.Effects[BLEED].Power
+		res += fmt.Sprintf("%s bleeds for %d damage\n", c.PlayerName,
+			c.Effects[BLEED].Power)
+	}
+	if c.has(BURN) {
+		handleDamage(c, c.Effects[BURN].Power, false)
+		res += fmt.Sprintf("%s burns for %d damage\n", c.PlayerName,
+			c.Effects[BURN].Power)
+	}
+	if c.has(STUN) {
+		res += fmt.Sprintf("%s is stunned\n", c.PlayerName)
+	}
+	return res
+}
+
+// expireEffects counts down the effects of c at the end of a turn
+func expireEffects(c *Class) {
+	for k := range c.Effects {
+		if c.Effects[k].Turns > 0 {
+			c.Effects[k].Turns--
+		}
+		if c.Effects[k].Turns == 0 {
+			c.Effects[k].Power = 0
+		}
+	}
+}
+
+// applyMoveEffect puts the effect of the successful move m of c, if it has
+// one, on c or on its opponent e rolling its chance with dice d. An effect
+// already active is refreshed to the stronger and longer of both
+func applyMoveEffect(c, e *Class, m MoveDef, d Dice) string {
+	if m.Effect == nil {
+		return ""
+	}
+	return applyEffect(c, e, *m.Effect, d)
+}
+
+// applyEffect puts the effect spec on c if it is a shield and otherwise on
+// its opponent e, rolling its chance with dice d
+func applyEffect(c, e *Class, spec EffectSpec, d Dice) string {
+	if spec.Chance > 0 && d.Float32() >= spec.Chance {
+		return ""
+	}
+	k, err := ParseEffectKind(spec.Kind)
+	if err != nil {
+		return ""
+	}
+
+	target := e
+	if k == SHIELDED {
+		target = c
+	}
+	eff := &target.Effects[k]
+	if spec.Turns > eff.Turns {
+		eff.Turns = spec.Turns
+	}
+	if spec.Power > eff.Power {
+		eff.Power = spec.Power
+	}
+	return fmt.Sprintf("%s is under %s for %d turns\n", target.PlayerName,
+		k, eff.Turns)
+}
+
+// absorb takes as much of damage d as the shield of c can hold and returns
+// the damage left
+func absorb(c *Class, d int) int {
+	if !c.has(SHIELDED) {
+		return d
+	}
+	shield := &c.Effects[SHIELDED]
+	if d < shield.Power {
+		shield.Power -= d
+		return 0
+	}
+	d -= shield.Power
+	shield.Power, shield.Turns = 0, 0
+	return d
+}
@@ -0,0 +1,108 @@
+package game
+
+import "testing"
+
+// TestDamageOverTime checks that bleed and burn tick at the start of each
+// turn, bleed skipping armor, and expire after their turns
+func TestDamageOverTime(t *testing.T) {
+	p := Class{Health: 50, Armor: 10}
+	e := Class{Health: 50}
+	p.Effects[BLEED] = Effect{Turns: 2, Power: 3}
+	p.Effects[BURN] = Effect{Turns: 1, Power: 4}
+
+	nothing := fixedDice{1, 0}
+	resolveTurn(&p, &e, BLOCK, BLOCK, nothing)
+	if p.Health != 47 || p.Armor != 6 {
+		t.Errorf("Got %d health %d armor, expected 47 health 6 armor",
+			p.Health, p.Armor)
+	}
+	if p.has(BURN) || !p.has(BLEED) {
+		t.Errorf("Got effects %s, expected bleed (1)", p.EffectsText())
+	}
+
+	resolveTurn(&p, &e, BLOCK, BLOCK, nothing)
+	resolveTurn(&p, &e, BLOCK, BLOCK, nothing)
+	if p.Health != 44 || p.EffectsText() != "none" {
+		t.Errorf("Got %d health with effects %s, expected 44 and none",
+			p.Health, p.EffectsText())
+	}
+}
+
+// TestStun checks that a stunned character does nothing, so an attack
+// against it lands, and spends no stamina
+func TestStun(t *testing.T) {
+	p := Class{Health: 50, Stamina: 20, MaxStamina: 30, Strength: 1}
+	e := Class{Health: 50, Stamina: 30, MaxStamina: 30, Strength: 1}
+	p.Effects[STUN] = Effect{Turns: 1}
+
+	hit := fixedDice{.5, 20}
+	resolveTurn(&p, &e, HEAVY, HEAVY, hit)
+	if e.Health != 50 || p.Health != 29 {
+		t.Errorf("Got health %d and %d, expected 29 and 50", p.Health,
+			e.Health)
+	}
+	if p.Stamina != 20 || p.has(STUN) {
+		t.Errorf("Got stamina %d with effects %s, expected 20 and none",
+			p.Stamina, p.EffectsText())
+	}
+}
+
+// TestShield checks that shields absorb damage before armor and break once
+// their points are used up
+func TestShield(t *testing.T) {
+	p := Class{Health: 50, Armor: 10}
+	p.Effects[SHIELDED] = Effect{Turns: 3, Power: 6}
+
+	handleDamage(&p, 4, false)
+	if p.Armor != 10 || p.Effects[SHIELDED].Power != 2 {
+		t.Errorf("Got %d armor and shield %d, expected 10 and 2", p.Armor,
+			p.Effects[SHIELDED].Power)
+	}
+	handleDamage(&p, 5, false)
+	if p.Armor != 7 || p.has(SHIELDED) {
+		t.Errorf("Got %d armor with effects %s, expected 7 and none",
+			p.Armor, p.EffectsText())
+	}
+}
+
+// TestMoveEffects checks that successful moves apply the effects of their
+// class to the right character
+func TestMoveEffects(t *testing.T) {
+	archer := mustClass("Archer", "a")
+	wizard := mustClass("Wizard", "w")
+	archer.Intellect, wizard.Intellect, wizard.Strength = 1, 0, 1
+
+	// the archer heavy attack lands while the wizard heavy attack misses
+	resolveTurn(&archer, &wizard, HEAVY, HEAVY, fixedDice{.5, 20})
+	if wizard.Effects[BLEED] != (Effect{Turns: 3, Power: 2}) ||
+		archer.EffectsText() != "none" {
+		t.Errorf("Got effects %s and %s, expected none and bleed (3)",
+			archer.EffectsText(), wizard.EffectsText())
+	}
+
+	// the wizard blocks a quick attack with its intellect and shields itself
+	wizard.Intellect = 1
+	resolveTurn(&archer, &wizard, QUICK, BLOCK, fixedDice{.5, 20})
+	if wizard.Effects[SHIELDED] != (Effect{Turns: 2, Power: 6}) {
+		t.Errorf("Got wizard effects %s, expected shielded (2)",
+			wizard.EffectsText())
+	}
+}
+
+// TestEffectStates checks that every effect on either character changes the
+// state
+func TestEffectStates(t *testing.T) {
+	p := mustClass("Knight", "p")
+	e := mustClass("Archer", "e")
+	seen := map[uint32]bool{getState(&p, &e): true}
+	for k := BLEED; k < nEffects; k++ {
+		for _, c := range []*Class{&p, &e} {
+			c.Effects[k] = Effect{Turns: 1}
+			seen[getState(&p, &e)] = true
+			c.Effects[k] = Effect{}
+		}
+	}
+	if len(seen) != 1+2*nEffects {
+		t.Errorf("Got %d states, expected %d", len(seen), 1+2*nEffects)
+	}
+}
@@ -0,0 +1,44 @@
+package game
+
+// MoveScore is the score an agent gave to a single move
+type MoveScore struct {
+	Move  string
+	Score float32
+}
+
+// Explanation describes how the AI picked its move in a turn
+type Explanation struct {
+	Agent      string
+	Difficulty string
+	State      uint32      // encoded state as used by the qtable
+	Scores     []MoveScore // qtable values, network outputs or minmax weights
+	Best       string      // best scored affordable move, empty if unscored
+	Move       string      // move actually played
+	Explored   bool        // whether the agent explored away from the best
+	Weakened   bool        // whether the difficulty replaced the move
+	Exhausted  bool        // whether the move was replaced for lack of stamina
+}
+
+// explainTurn explains the move the AI is about to pick against p with e,
+// scoring the moves with agent a called name before the move is played and
+// learned from. It also returns the scores, nil for agents which can't score
+func explainTurn(a Agent, name string, p, e *Class) (Explanation,
+	[]float32) {
+	ex := Explanation{
+		Agent:      name,
+		Difficulty: p.Difficulty.String(),
+		State:      getState(p, e),
+	}
+
+	s, ok := a.(Scorer)
+	if !ok {
+		return ex, nil
+	}
+
+	scores := s.Scores(p, e)
+	for i, v := range scores {
+		ex.Scores = append(ex.Scores, MoveScore{Move(i).String(), v})
+	}
+	ex.Best = bestLegal(e, scores, sharedDice{}).String()
+	return ex, scores
+}
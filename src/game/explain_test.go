@@ -0,0 +1,36 @@
+package game
+
+import "testing"
+
+// TestAIGetTurnExplained checks that explanations are taken when the move is
+// chosen, telling exploration apart from difficulty and stamina replacements
+func TestAIGetTurnExplained(t *testing.T) {
+	p := mustClass("Knight", "p")
+	e := mustClass("Archer", "e")
+	e.MaxStamina, e.Stamina = 100, 0
+
+	for _, diff := range []Difficulty{DIFF_EASY, DIFF_HARD} {
+		p.Difficulty = diff
+		for i := 0; i < nClassTests; i++ {
+			m, ex := AIGetTurn(&p, &e)
+			if !e.CanAfford(m) || ex.Move != m.String() {
+				t.Fatalf("Got move %s explained as %s, expected an"+
+					" affordable move", m, ex.Move)
+			}
+			if ex.Agent != "minmax" || len(ex.Scores) != 6 {
+				t.Fatalf("Got agent %s with %d scores, expected minmax"+
+					" with 6", ex.Agent, len(ex.Scores))
+			}
+			if ex.Explored && (ex.Weakened || ex.Exhausted) {
+				t.Errorf("Got %s explored while replaced", m)
+			}
+			if ex.Exhausted && !ex.Weakened && ex.Move != ex.Best {
+				t.Errorf("Got %s replaced for stamina, expected the best"+
+					" affordable move %s", m, ex.Best)
+			}
+			if ex.Weakened && diff == DIFF_HARD {
+				t.Errorf("Got a move weakened at hard difficulty")
+			}
+		}
+	}
+}
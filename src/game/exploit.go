@@ -0,0 +1,359 @@
+package game
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// exploit detection settings
+const (
+	exploitWindow     = 12  // recent player moves the entropy is measured on
+	entropyThreshold  = 1.2 // bits, uniform play over 6 moves is about 2.58
+	streakThreshold   = 5   // same move played this many times in a row
+	minPatternMatches = 5   // matches before a pattern win rate is trusted
+	patternWinRate    = .65 // pattern win rate reported as dominant
+	counterSamples    = 50  // simulated turns per move pair of the counter
+)
+
+// FINDINGS_FILE is the file balance findings are appended to as json lines
+const FINDINGS_FILE = "findings.jsonl"
+
+// CounterExploits is set by main using cmd line flags, when set the AI
+// switches to a counter strategy against players using a degenerate one
+var CounterExploits bool
+
+// Finding is a degenerate strategy detected while a player was playing
+type Finding struct {
+	Time   time.Time
+	Player string
+	Class  string
+	Kind   string  // "low-entropy", "streak" or "dominant-pattern"
+	Move   string  // move the strategy relies on
+	Value  float32 // entropy in bits, streak length or pattern win rate
+}
+
+// moveTracker follows the recent moves of a single player
+type moveTracker struct {
+	recent []Move          // last exploitWindow moves
+	last   Move            // last move played
+	streak int             // times last was played in a row
+	match  map[Move]int    // moves played in the current match
+	logged map[string]bool // finding kinds reported in the current match
+}
+
+// patternRecord counts the results of matches a pattern was played in
+type patternRecord struct {
+	Wins   int
+	Losses int
+}
+
+var trackers = make(map[string]*moveTracker)
+var patterns map[string]*patternRecord
+var exploitLock sync.Mutex
+
+// counterAgent plays the move with the best simulated outcome against the
+// predicted distribution of player moves
+type counterAgent struct {
+	predicted []float32
+}
+
+// entropy returns the shannon entropy in bits of the move counts
+func entropy(counts map[Move]int) float32 {
+	var total int
+	for _, n := range counts {
+		total += n
+	}
+
+	var h float64
+	for _, n := range counts {
+		if n == 0 {
+			continue
+		}
+		p := float64(n) / float64(total)
+		h -= p * math.Log2(p)
+	}
+	return float32(h)
+}
+
+// dominant returns the most played move of the counts
+func dominant(counts map[Move]int) Move {
+	var m Move
+	for move, n := range counts {
+		if n > counts[m] || n == counts[m] && move < m {
+			m = move
+		}
+	}
+	return m
+}
+
+// recentCounts returns how often each move was played in the window
+func (t *moveTracker) recentCounts() map[Move]int {
+	counts := make(map[Move]int)
+	for _, m := range t.recent {
+		counts[m]++
+	}
+	return counts
+}
+
+// observe adds move m to the history and returns the findings it triggers,
+// each kind of finding is only reported once per match
+func (t *moveTracker) observe(p *Class, m Move) []Finding {
+	t.recent = append(t.recent, m)
+	if len(t.recent) > exploitWindow {
+		t.recent = t.recent[1:]
+	}
+	if m == t.last && t.streak > 0 {
+		t.streak++
+	} else {
+		t.last, t.streak = m, 1
+	}
+	t.match[m]++
+
+	var findings []Finding
+	report := func(kind string, m Move, v float32) {
+		if t.logged[kind] {
+			return
+		}
+		t.logged[kind] = true
+		findings = append(findings, Finding{time.Now(), p.PlayerName,
+			p.ClassName, kind, m.String(), v})
+	}
+
+	if t.streak >= streakThreshold {
+		report("streak", t.last, float32(t.streak))
+	}
+	if len(t.recent) == exploitWindow {
+		counts := t.recentCounts()
+		if h := entropy(counts); h < entropyThreshold {
+			report("low-entropy", dominant(counts), h)
+		}
+	}
+	return findings
+}
+
+// exploiting returns whether the player is currently using a degenerate
+// strategy
+func (t *moveTracker) exploiting() bool {
+	if t.streak >= streakThreshold {
+		return true
+	}
+	return len(t.recent) == exploitWindow &&
+		entropy(t.recentCounts()) < entropyThreshold
+}
+
+// predict returns the probability of the player playing each move next, the
+// streak move if there is one and the recent frequencies otherwise
+func (t *moveTracker) predict() []float32 {
+	probs := make([]float32, 6)
+	if t.streak >= streakThreshold {
+		probs[t.last] = 1
+		return probs
+	}
+	for _, m := range t.recent {
+		probs[m] += 1 / float32(len(t.recent))
+	}
+	return probs
+}
+
+// newMoveTracker returns a tracker of a player without any moves
+func newMoveTracker() *moveTracker {
+	return &moveTracker{
+		match:  make(map[Move]int),
+		logged: make(map[string]bool),
+	}
+}
+
+// getTracker returns the tracker of player, creating one if needed. Only
+// call with exploitLock held
+func getTracker(player string) *moveTracker {
+	t, ok := trackers[player]
+	if !ok {
+		t = newMoveTracker()
+		trackers[player] = t
+	}
+	return t
+}
+
+// ObservePlayerMove records the move m player p made in a turn, logging any
+// degenerate strategy it reveals. At the end of a match the dominant move of
+// the player is scored as a pattern of their class
+func ObservePlayerMove(p *Class, m Move, end, playerWon bool) {
+	exploitLock.Lock()
+	defer exploitLock.Unlock()
+
+	t := getTracker(p.PlayerName)
+	findings := t.observe(p, m)
+	if end {
+		findings = append(findings, endMatch(t, p, playerWon)...)
+	}
+
+	for _, f := range findings {
+		logFinding(f)
+	}
+}
+
+// ObservePlayerItem records that player p used an item in place of a move,
+// which counts towards none of its strategies but can end the match
+func ObservePlayerItem(p *Class, end, playerWon bool) {
+	exploitLock.Lock()
+	defer exploitLock.Unlock()
+
+	if end {
+		for _, f := range endMatch(getTracker(p.PlayerName), p, playerWon) {
+			logFinding(f)
+		}
+	}
+}
+
+// endMatch scores the dominant move of the match p finished and resets the
+// match of tracker t. Only call with exploitLock held
+func endMatch(t *moveTracker, p *Class, playerWon bool) []Finding {
+	var findings []Finding
+	if len(t.match) > 0 {
+		if f, ok := recordPattern(p, dominant(t.match), playerWon); ok {
+			findings = append(findings, f)
+		}
+	}
+	t.match = make(map[Move]int)
+	t.logged = make(map[string]bool)
+	return findings
+}
+
+// recordPattern scores the match result of the class played mostly with
+// move m, returning a finding if the pattern wins too often. Only call with
+// exploitLock held
+func recordPattern(p *Class, m Move, playerWon bool) (Finding, bool) {
+	if patterns == nil {
+		loadPatterns()
+	}
+	key := p.ClassName + " " + m.String()
+	r, ok := patterns[key]
+	if !ok {
+		r = &patternRecord{}
+		patterns[key] = r
+	}
+	if playerWon {
+		r.Wins++
+	} else {
+		r.Losses++
+	}
+	savePatterns()
+
+	n := r.Wins + r.Losses
+	rate := float32(r.Wins) / float32(n)
+	if n < minPatternMatches || rate < patternWinRate {
+		return Finding{}, false
+	}
+	return Finding{time.Now(), p.PlayerName, p.ClassName, "dominant-pattern",
+		m.String(), rate}, true
+}
+
+// logFinding prints finding f and appends it to FINDINGS_FILE
+func logFinding(f Finding) {
+	fmt.Printf("balance finding: %s %s %s %s %.2f\n", f.Player, f.Class,
+		f.Kind, f.Move, f.Value)
+
+	file, err := os.OpenFile(FINDINGS_FILE,
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+
+	body, err := json.Marshal(f)
+	if err != nil {
+		panic(err)
+	}
+	_, err = file.Write(append(body, '\n'))
+	if err != nil {
+		panic(err)
+	}
+}
+
+// loadPatterns loads pattern stats from file if it exists. Only call with
+// exploitLock held
+func loadPatterns() {
+	patterns = make(map[string]*patternRecord)
+
+	f, err := os.Open("patterns")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(f)
+	err = dec.Decode(&patterns)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// savePatterns saves pattern stats to disk. Only call with exploitLock held
+func savePatterns() {
+	f, err := os.Create("patterns")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	enc := gob.NewEncoder(f)
+	err = enc.Encode(patterns)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// counterFor returns a counter agent against player if counters are enabled
+// and the player is using a degenerate strategy
+func counterFor(player string) (Agent, bool) {
+	if !CounterExploits {
+		return nil, false
+	}
+
+	exploitLock.Lock()
+	defer exploitLock.Unlock()
+
+	t, ok := trackers[player]
+	if !ok || !t.exploiting() {
+		return nil, false
+	}
+	return counterAgent{t.predict()}, true
+}
+
+func (a counterAgent) GetTurn(p, e *Class) Move {
+	return bestMove(a.Scores(p, e))
+}
+
+// margin returns how much more health and armor the player lost than the AI
+// going from p and e to nextP and nextE
+func margin(p, e, nextP, nextE *Class) float32 {
+	lost := p.Health + p.Armor - nextP.Health - nextP.Armor
+	aiLost := e.Health + e.Armor - nextE.Health - nextE.Armor
+	return float32(lost - aiLost)
+}
+
+// Scores returns the mean simulated margin of every move against the
+// predicted player moves
+func (a counterAgent) Scores(p, e *Class) []float32 {
+	scores := make([]float32, 6)
+	for m := range scores {
+		for pm, prob := range a.predicted {
+			if prob == 0 {
+				continue
+			}
+			var sum float32
+			for i := 0; i < counterSamples; i++ {
+				np, ne := *p, *e
+				resolveTurn(&np, &ne, Move(pm), Move(m), sharedDice{})
+				sum += margin(p, e, &np, &ne)
+			}
+			scores[m] += prob * sum / counterSamples
+		}
+	}
+	return scores
+}
@@ -0,0 +1,82 @@
+package game
+
+import "testing"
+
+// TestExploitStreak checks that a streak is reported once and predicted
+func TestExploitStreak(t *testing.T) {
+	tr := newMoveTracker()
+	p := mustClass("Archer", "streaker")
+
+	var found int
+	for i := 0; i < streakThreshold+2; i++ {
+		for _, f := range tr.observe(&p, PARRY) {
+			if f.Kind == "streak" {
+				found++
+			}
+		}
+	}
+	if found != 1 {
+		t.Errorf("Got %d streak findings, expected 1", found)
+	}
+	if !tr.exploiting() {
+		t.Errorf("Streak not detected as exploiting")
+	}
+	if probs := tr.predict(); probs[PARRY] != 1 {
+		t.Errorf("Got parry probability %f, expected 1", probs[PARRY])
+	}
+}
+
+// TestExploitEntropy checks that varied play is not flagged while play
+// alternating between two moves is
+func TestExploitEntropy(t *testing.T) {
+	p := mustClass("Knight", "varied")
+	varied := newMoveTracker()
+	for i := 0; i < exploitWindow; i++ {
+		varied.observe(&p, Move(i%6))
+	}
+	if varied.exploiting() {
+		t.Errorf("Varied play detected as exploiting")
+	}
+
+	p = mustClass("Knight", "pair")
+	pair := newMoveTracker()
+	var found bool
+	for i := 0; i < exploitWindow; i++ {
+		for _, f := range pair.observe(&p, Move(i%2)) {
+			found = found || f.Kind == "low-entropy"
+		}
+	}
+	if !found || !pair.exploiting() {
+		t.Errorf("Alternating play not detected, entropy %f",
+			entropy(pair.recentCounts()))
+	}
+}
+
+// fixedAgent always plays the same move
+type fixedAgent struct {
+	m Move
+}
+
+func (a fixedAgent) GetTurn(p, e *Class) Move {
+	return a.m
+}
+
+// TestCounterAgent checks that the counter scores better than random play,
+// wins minus losses, against a player repeating a single move
+func TestCounterAgent(t *testing.T) {
+	for _, m := range []Move{HEAVY, PARRY} {
+		predicted := make([]float32, 6)
+		predicted[m] = 1
+
+		var counterScore, randScore int
+		for i := 0; i < 40; i++ {
+			counterScore += PlayMatch(counterAgent{predicted}, fixedAgent{m},
+				"")
+			randScore += PlayMatch(randAgent{}, fixedAgent{m}, "")
+		}
+		if counterScore <= randScore {
+			t.Errorf("Counter scored %d against %s, random scored %d",
+				counterScore, m, randScore)
+		}
+	}
+}
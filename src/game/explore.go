@@ -0,0 +1,277 @@
+package game
+
+import (
+	"errors"
+	"math"
+)
+
+type ExploreKind int
+
+const (
+	EXPLORE_CONSTANT ExploreKind = iota
+	EXPLORE_LINEAR
+	EXPLORE_EXPONENTIAL
+	EXPLORE_BOLTZMANN
+	EXPLORE_UCB
+)
+
+// EXPLORE_POLICY, ExploreMin, ExploreDecay, ExploreExpDecay, Temperature and
+// UCBWeight are set by main using cmd line flags and configure the
+// exploration of new reinforcement agents together with ExploreRate
+var EXPLORE_POLICY ExploreKind
+var ExploreMin float32
+var ExploreDecay float32
+var ExploreExpDecay float32
+var Temperature float32
+var UCBWeight float32
+
+// ExplorePolicy chooses the moves of a reinforcement agent from the values of
+// the current state. Policies keep their schedule state so every agent needs
+// its own, and are always called with the agent locked
+type ExplorePolicy interface {
+	// Select picks the move to play in state rolling with dice d
+	Select(d Dice, state uint32, values []float32) Move
+	// Probs returns the probability of Select picking each move in state
+	Probs(state uint32, values []float32) []float32
+	// EndEpisode advances the schedule after every match
+	EndEpisode()
+}
+
+// epsilonGreedy plays a random move with probability Epsilon and the best
+// move otherwise
+type epsilonGreedy struct {
+	Epsilon float32
+}
+
+// ConstantEpsilon is epsilon greedy with a fixed epsilon
+type ConstantEpsilon struct {
+	epsilonGreedy
+}
+
+// LinearEpsilon is epsilon greedy where epsilon decreases by Decay after every
+// match down to Min
+type LinearEpsilon struct {
+	epsilonGreedy
+	Decay float32
+	Min   float32
+}
+
+// ExponentialEpsilon is epsilon greedy where epsilon is multiplied by Decay
+// after every match down to Min
+type ExponentialEpsilon struct {
+	epsilonGreedy
+	Decay float32
+	Min   float32
+}
+
+// Boltzmann selects moves with probability given by the softmax of their
+// values divided by Temperature
+type Boltzmann struct {
+	Temperature float32
+}
+
+// UCB plays the move with the highest upper confidence bound, which adds a
+// bonus of Weight scaled by how rarely a move was played in a state
+type UCB struct {
+	Weight float32
+	counts map[uint32][]int
+}
+
+// ParseExploreKind converts an exploration policy name into an ExploreKind
+func ParseExploreKind(s string) (ExploreKind, error) {
+	switch s {
+	case "constant":
+		return EXPLORE_CONSTANT, nil
+	case "linear":
+		return EXPLORE_LINEAR, nil
+	case "exp":
+		return EXPLORE_EXPONENTIAL, nil
+	case "boltzmann":
+		return EXPLORE_BOLTZMANN, nil
+	case "ucb":
+		return EXPLORE_UCB, nil
+	}
+	return EXPLORE_CONSTANT, errors.New("Could not parse explore policy")
+}
+
+// String returns the name ParseExploreKind accepts for the policy
+func (k ExploreKind) String() string {
+	switch k {
+	case EXPLORE_LINEAR:
+		return "linear"
+	case EXPLORE_EXPONENTIAL:
+		return "exp"
+	case EXPLORE_BOLTZMANN:
+		return "boltzmann"
+	case EXPLORE_UCB:
+		return "ucb"
+	default:
+		return "constant"
+	}
+}
+
+// UsesRate returns whether policies of the kind explore by the explore rate,
+// boltzmann and ucb ignore it
+func (k ExploreKind) UsesRate() bool {
+	return k != EXPLORE_BOLTZMANN && k != EXPLORE_UCB
+}
+
+// ValidateExplore checks that the settings set by main suit policies of kind
+func ValidateExplore(kind ExploreKind) error {
+	switch kind {
+	case EXPLORE_EXPONENTIAL:
+		if ExploreExpDecay <= 0 || ExploreExpDecay >= 1 {
+			return errors.New("Exponential explore decay must be between" +
+				" 0 and 1")
+		}
+	case EXPLORE_BOLTZMANN:
+		if Temperature <= 0 {
+			return errors.New("Boltzmann temperature must be above 0")
+		}
+	case EXPLORE_UCB:
+		if UCBWeight < 0 {
+			return errors.New("UCB weight must not be negative")
+		}
+	}
+	return nil
+}
+
+// NewExplorePolicy creates a new exploration policy of the kind selected by
+// EXPLORE_POLICY
+func NewExplorePolicy() ExplorePolicy {
+	return newExplorePolicy(EXPLORE_POLICY, ExploreRate)
+}
+
+// newExplorePolicy creates a new exploration policy of kind starting from
+// explore rate rate, other settings are taken from the values set by main
+func newExplorePolicy(kind ExploreKind, rate float32) ExplorePolicy {
+	switch kind {
+	case EXPLORE_LINEAR:
+		return &LinearEpsilon{epsilonGreedy{rate}, ExploreDecay, ExploreMin}
+	case EXPLORE_EXPONENTIAL:
+		return &ExponentialEpsilon{epsilonGreedy{rate}, ExploreExpDecay,
+			ExploreMin}
+	case EXPLORE_BOLTZMANN:
+		return &Boltzmann{Temperature}
+	case EXPLORE_UCB:
+		return &UCB{Weight: UCBWeight}
+	default:
+		return &ConstantEpsilon{epsilonGreedy{rate}}
+	}
+}
+
+func (p *epsilonGreedy) Select(d Dice, state uint32, values []float32) Move {
+	if d.Float32() < p.Epsilon {
+		return Move(d.Intn(6))
+	}
+	return bestMove(values)
+}
+
+func (p *epsilonGreedy) Probs(state uint32, values []float32) []float32 {
+	probs := make([]float32, len(values))
+	for i := range probs {
+		probs[i] = p.Epsilon / float32(len(values))
+	}
+	probs[bestMove(values)] += 1 - p.Epsilon
+	return probs
+}
+
+// Rate returns the current epsilon
+func (p *epsilonGreedy) Rate() float32 {
+	return p.Epsilon
+}
+
+func (p *ConstantEpsilon) EndEpisode() {}
+
+func (p *LinearEpsilon) EndEpisode() {
+	p.Epsilon -= p.Decay
+	if p.Epsilon < p.Min {
+		p.Epsilon = p.Min
+	}
+}
+
+func (p *ExponentialEpsilon) EndEpisode() {
+	p.Epsilon *= p.Decay
+	if p.Epsilon < p.Min {
+		p.Epsilon = p.Min
+	}
+}
+
+func (p *Boltzmann) Select(d Dice, state uint32, values []float32) Move {
+	r := d.Float32()
+	for i, v := range p.Probs(state, values) {
+		r -= v
+		if r <= 0 {
+			return Move(i)
+		}
+	}
+	return Move(len(values) - 1)
+}
+
+func (p *Boltzmann) Probs(state uint32, values []float32) []float32 {
+	// subtract the max value to keep exp from overflowing
+	max := values[bestMove(values)]
+
+	probs := make([]float32, len(values))
+	if p.Temperature <= 0 {
+		// the softmax of a temperature of 0 or below is undefined, play
+		// greedily rather than dividing by it
+		probs[bestMove(values)] = 1
+		return probs
+	}
+	var sum float32
+	for i, v := range values {
+		probs[i] = float32(math.Exp(float64((v - max) / p.Temperature)))
+		sum += probs[i]
+	}
+	for i := range probs {
+		probs[i] /= sum
+	}
+	return probs
+}
+
+func (p *Boltzmann) EndEpisode() {}
+
+// bounds returns the upper confidence bound of every move in state, moves
+// which were never played get an infinite bound
+func (p *UCB) bounds(state uint32, values []float32) []float32 {
+	counts := p.counts[state]
+
+	var total int
+	for _, n := range counts {
+		total += n
+	}
+
+	bounds := make([]float32, len(values))
+	for i, v := range values {
+		if counts == nil || counts[i] == 0 {
+			bounds[i] = math.MaxFloat32
+			continue
+		}
+		bonus := math.Sqrt(math.Log(float64(total)) / float64(counts[i]))
+		bounds[i] = v + p.Weight*float32(bonus)
+	}
+	return bounds
+}
+
+func (p *UCB) Select(d Dice, state uint32, values []float32) Move {
+	m := bestMove(p.bounds(state, values))
+
+	if p.counts == nil {
+		p.counts = make(map[uint32][]int)
+	}
+	if p.counts[state] == nil {
+		p.counts[state] = make([]int, len(values))
+	}
+	p.counts[state][m]++
+
+	return m
+}
+
+func (p *UCB) Probs(state uint32, values []float32) []float32 {
+	probs := make([]float32, len(values))
+	probs[bestMove(p.bounds(state, values))] = 1
+	return probs
+}
+
+func (p *UCB) EndEpisode() {}
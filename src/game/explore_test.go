@@ -0,0 +1,99 @@
+package game
+
+import "testing"
+
+// TestExploreProbs checks that the move probabilities of every policy sum to 1
+func TestExploreProbs(t *testing.T) {
+	policies := map[string]ExplorePolicy{
+		"constant":  &ConstantEpsilon{epsilonGreedy{.1}},
+		"boltzmann": &Boltzmann{Temperature: .5},
+		"ucb":       &UCB{Weight: 1},
+	}
+	values := []float32{.5, -1, 2, 0, 1.5, 3}
+
+	for name, p := range policies {
+		var sum float32
+		for _, v := range p.Probs(0, values) {
+			sum += v
+		}
+		if sum < .999 || sum > 1.001 {
+			t.Errorf("Probs of %s sum to %f, expected 1", name, sum)
+		}
+	}
+}
+
+// TestEpsilonDecay checks that decaying policies stop at their minimum
+func TestEpsilonDecay(t *testing.T) {
+	lin := &LinearEpsilon{epsilonGreedy{.5}, .1, .05}
+	exp := &ExponentialEpsilon{epsilonGreedy{.5}, .5, .05}
+	for i := 0; i < 10; i++ {
+		lin.EndEpisode()
+		exp.EndEpisode()
+	}
+	if lin.Epsilon != .05 {
+		t.Errorf("Got linear epsilon %f, expected .05", lin.Epsilon)
+	}
+	if exp.Epsilon != .05 {
+		t.Errorf("Got exponential epsilon %f, expected .05", exp.Epsilon)
+	}
+}
+
+// TestUCBTriesEveryMove checks that ucb plays every move once before
+// repeating any
+func TestUCBTriesEveryMove(t *testing.T) {
+	p := &UCB{Weight: 1}
+	values := make([]float32, 6)
+	seen := make(map[Move]bool)
+	for i := 0; i < len(values); i++ {
+		seen[p.Select(sharedDice{}, 0, values)] = true
+	}
+	if len(seen) != len(values) {
+		t.Errorf("Got %d distinct moves, expected %d", len(seen), len(values))
+	}
+}
+
+// TestBoltzmannZeroTemperature checks that a temperature of 0 plays greedily
+// rather than dividing by it, and that main rejects it
+func TestBoltzmannZeroTemperature(t *testing.T) {
+	defer func() { Temperature = 0 }()
+
+	p := &Boltzmann{}
+	values := []float32{.5, -1, 2, 0, 1.5, 3}
+	if m := p.Select(fixedDice{.5, 0}, 0, values); m != EVADE {
+		t.Errorf("Got move %s, expected the best move %s", m, EVADE)
+	}
+	values[EVADE] = -2
+	if m := p.Select(fixedDice{.5, 0}, 0, values); m != STANDARD {
+		t.Errorf("Got move %s, expected the best move %s", m, STANDARD)
+	}
+
+	if ValidateExplore(EXPLORE_BOLTZMANN) == nil {
+		t.Errorf("Accepted a boltzmann temperature of 0")
+	}
+	Temperature = .5
+	if err := ValidateExplore(EXPLORE_BOLTZMANN); err != nil {
+		t.Errorf("Rejected a boltzmann temperature of .5: %v", err)
+	}
+}
+
+// TestExpDecayBounds checks that main rejects exponential decays which would
+// collapse or grow the explore rate
+func TestExpDecayBounds(t *testing.T) {
+	defer func() { ExploreExpDecay = 0 }()
+
+	for _, decay := range []float32{0, .001, .999, 1} {
+		ExploreExpDecay = decay
+		err := ValidateExplore(EXPLORE_EXPONENTIAL)
+		if (decay > 0 && decay < 1) != (err == nil) {
+			t.Errorf("Got error %v for decay %f", err, decay)
+		}
+	}
+
+	ExploreExpDecay = .999
+	p := newExplorePolicy(EXPLORE_EXPONENTIAL, .5).(*ExponentialEpsilon)
+	p.EndEpisode()
+	if p.Epsilon < .49 {
+		t.Errorf("Got epsilon %f after one match, expected about .5",
+			p.Epsilon)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"fmt"
 	"io"
-	"math/rand"
 	"os"
 	"strconv"
 )
@@ -20,120 +19,48 @@ const (
 	EVADE
 )
 
+// String returns the name of the move as used by the web routes
+func (m Move) String() string {
+	switch m {
+	case HEAVY:
+		return "Heavy"
+	case QUICK:
+		return "Quick"
+	case STANDARD:
+		return "Standard"
+	case BLOCK:
+		return "Block"
+	case PARRY:
+		return "Parry"
+	case EVADE:
+		return "Evade"
+	}
+	return "Unknown"
+}
+
 // Class is the base player struct storing stats and data
 type Class struct {
 	PlayerName string
 	ClassName  string
-	Health     int     // capped 100
-	Armor      int     // capped 20
-	Strength   float32 // normalized
-	Dexterity  float32 // normalized
-	Intellect  float32 // normalized
-}
-
-// NewKnight generates a new knight class with initial values
-func NewKnight(playerName string) Class {
-	return Class{
-		PlayerName: playerName,
-		ClassName:  "Knight",
-		Health:     rand.Intn(20+1) + 80,
-		Armor:      rand.Intn(20 + 1),
-		Strength:   float32(rand.Intn(5+1)+15) / 20,
-		Dexterity:  float32(rand.Intn(5+1)+10) / 20,
-		Intellect:  float32(rand.Intn(5+1)+5) / 20,
-	}
-}
-
-// NewArcher generates a new arhcer lcass with initial values
-func NewArcher(playerName string) Class {
-	return Class{
-		PlayerName: playerName,
-		ClassName:  "Archer",
-		Health:     rand.Intn(20+1) + 80,
-		Armor:      rand.Intn(20 + 1),
-		Strength:   float32(rand.Intn(5+1)+5) / 20,
-		Dexterity:  float32(rand.Intn(5+1)+15) / 20,
-		Intellect:  float32(rand.Intn(5+1)+10) / 20,
-	}
-}
-
-// NewWizard generates a new wizard class with initial values
-func NewWizard(playerName string) Class {
-	return Class{
-		PlayerName: playerName,
-		ClassName:  "Wizard",
-		Health:     rand.Intn(20+1) + 80,
-		Armor:      rand.Intn(20 + 1),
-		Strength:   float32(rand.Intn(5+1)+10) / 20,
-		Dexterity:  float32(rand.Intn(5+1)+5) / 20,
-		Intellect:  float32(rand.Intn(5+1)+15) / 20,
-	}
-}
-
-// heavyAttack deals damaged based off of attackers strength but
-// success probability is determined based off defenders intellect
-func (c *Class) heavyAttack(e *Class) int {
-	// higher enemy intellect -> lower chance to hit
-	if rand.Float32() > e.Intellect {
-		// higher attacker strength -> more damage
-		return int(float32(rand.Intn(20+1))*c.Strength + 1.5)
-	} else {
-		return 0
-	}
-}
-
-// quickAttack deals damaged based off of attackers dexterity but
-// success probability is determined based off defenders strength
-func (c *Class) quickAttack(e *Class) int {
-	// higher enemy strength -> lower chance to hit
-	if rand.Float32() > e.Strength {
-		// higher attacker dexterity -> more damage
-		return int(float32(rand.Intn(20+1))*c.Dexterity + 1.5)
-	} else {
-		return 0
-	}
-}
-
-// standardAttack deals damaged based off of attacker intellect but
-// success probability is determined based off defenders dexterity
-func (c *Class) standardAttack(e *Class) int {
-	// higer enemy dexterity -> lower chance to hit
-	if rand.Float32() > e.Dexterity {
-		// higher attacker intellect -> more damage
-		return int(float32(rand.Intn(20+1))*c.Intellect + 1.5)
-	} else {
-		return 0
-	}
-}
-
-// block attempts to block an attack using strength to determine
-// success. on success blocker takes no damage and repairs armor
-func (c *Class) blockDefense(e *Class) int {
-	if rand.Float32() < c.Strength {
-		return rand.Intn(int(c.Strength*10 + .5))
-	} else {
-		return 0
-	}
-}
-
-// parry attempts to dodge an attack and counter, success based on
-// dexterity of person parrying, failure results in damaging self
-func (c *Class) parryDefense(e *Class) int {
-	if rand.Float32() < c.Dexterity {
-		return rand.Intn(int(c.Dexterity*10 + .5))
-	} else {
-		return -rand.Intn(int(c.Dexterity*10 + .5))
-	}
-}
-
-// evade attempts to evade an attack using intelligence to
-// determine success. on success evader takes no damage and heals
-func (c *Class) evadeDefense(e *Class) int {
-	if rand.Float32() < c.Intellect {
-		return rand.Intn(int(c.Intellect*10 + .5))
-	} else {
-		return 0
-	}
+	Health     int              // capped HealthCap
+	MaxHealth  int              // health restored between matches
+	Stamina    int              // capped MaxStamina
+	MaxStamina int              // stamina rolled at creation, 0 for no stamina
+	Armor      int              // capped ArmorCap
+	MaxArmor   int              // armor restored between matches
+	Strength   float32          // normalized
+	Dexterity  float32          // normalized
+	Intellect  float32          // normalized
+	Effects    [nEffects]Effect // lasting status effects by kind
+	Difficulty Difficulty       // difficulty of the AI playing against this char
+	Level      int              // 0 for characters created before levels
+	XP         int              // experience towards the next level
+	Points     int              // attribute points left to allocate
+	Wins       int              // matches won
+	Losses     int              // matches lost
+	Weapon     string           // equipped weapon, empty for none
+	Gear       string           // equipped armor piece, empty for none
+	Inventory  [maxItems]string // carried items, empty slots are empty
 }
 
 // PrintCharacter prints out a character information box with name
@@ -150,38 +77,41 @@ func PrintCharacter(p Class) {
 }
 
 // parseMove takes in two players and parses a move m for the first
-// player p1
-func parseMove(p1, p2 *Class, m Move) (bool, int) {
-	var def bool
-	var r int
-	switch m {
-	case HEAVY:
-		def = false
-		r = p1.heavyAttack(p2)
-	case QUICK:
-		def = false
-		r = p1.quickAttack(p2)
-	case STANDARD:
-		def = false
-		r = p1.standardAttack(p2)
-	case BLOCK:
-		def = true
-		r = p1.blockDefense(p2)
-	case PARRY:
-		def = true
-		r = p1.parryDefense(p2)
-	case EVADE:
-		def = true
-		r = p1.evadeDefense(p2)
+// player p1 rolling with dice d, returning the amount rolled and how an
+// attack landed
+func parseMove(p1, p2 *Class, m Move, d Dice) (int, hitKind) {
+	// an exhausted character attempting an attack it can't afford misses
+	if !p1.CanAfford(m) {
+		return 0, NORMAL_HIT
+	}
+	// a stunned character does nothing and a burning one fails some moves
+	if p1.has(STUN) {
+		return 0, NORMAL_HIT
+	}
+	if p1.has(BURN) && d.Float32() < burnFailChance {
+		return 0, NORMAL_HIT
+	}
+
+	def := MoveOf(p1, m)
+	r, kind := def.roll(p1, p2, d)
+	if def.Outcome == ATTACK {
+		r = scale(r, traitsOf(p1).AttackScale)
+		r = scale(r, 1+p1.gear().Damage)
+	} else if def.Outcome == HEAL {
+		r = scale(r, traitsOf(p1).HealScale)
 	}
 
-	return def, r
+	return r, kind
 }
 
-// handleDamage parses damage to determine armor and health effects
-func handleDamage(p *Class, d int) {
+// handleDamage parses damage to determine armor and health effects, piercing
+// damage skips shields and armor
+func handleDamage(p *Class, d int, pierce bool) {
+	if !pierce {
+		d = absorb(p, d)
+	}
 	// armor absorbs all damage as long as armor health >0
-	if p.Armor > 0 {
+	if p.Armor > 0 && !pierce {
 		p.Armor -= d
 		if p.Armor < 0 {
 			p.Armor = 0
@@ -192,25 +122,20 @@ func handleDamage(p *Class, d int) {
 }
 
 // printTurn prints out text regarding outcome of turn
-func printTurn(p1, p2 *Class, m1, m2 Move, s1, s2 bool) string {
+func printTurn(p1, p2 *Class, m1, m2 MoveDef, s1, s2 bool) string {
 	var res string
-	var moveNames = map[Move]string{
-		HEAVY:    "heavy attack",
-		QUICK:    "quick attack",
-		STANDARD: "standard attack",
-		BLOCK:    "block",
-		PARRY:    "parry",
-		EVADE:    "evade",
-	}
 	var result = map[bool]string{
 		false: "fails",
 		true:  "succeeds",
 	}
 
-	res += fmt.Sprintf("%s attempts to %s %s and %s\n",
-		p1.PlayerName, moveNames[m1], p2.PlayerName, result[s1])
-	res += fmt.Sprintf("%s attempts to %s %s and %s\n",
-		p2.PlayerName, moveNames[m2], p1.PlayerName, result[s2])
+	// characters using items describe it themselves
+	if m1.Outcome != itemOutcome {
+		res += fmt.Sprintf("%s uses %s against %s and %s\n",
+			p1.PlayerName, m1.Name, p2.PlayerName, result[s1])
+	}
+	res += fmt.Sprintf("%s uses %s against %s and %s\n",
+		p2.PlayerName, m2.Name, p1.PlayerName, result[s2])
 
 	return res
 }
@@ -240,146 +165,100 @@ func SelectMove(w io.Writer, p Class) Move {
 	return Move(getIntInput(w))
 }
 
+// TurnHits is how the attacks of both characters landed in a turn
+type TurnHits struct {
+	Kind1, Kind2     string // hit, crit or glancing, empty if nothing landed
+	Damage1, Damage2 int    // damage dealt to the opponent
+}
+
 // Turn takes in two players and two moves and and handles the events
-// which occur from player p1 executing move m1 and p2 executing m2
-func Turn(p1, p2 *Class, m1, m2 Move) (string, bool) {
-	var state uint16
-	if AI_ALG == AI_REINFORCEMENT {
-		state = getState(p1, p2)
+// which occur from player p1 executing move m1 and p2 executing m2. When
+// training the AI learns from the move m2 it played
+func Turn(p1, p2 *Class, m1, m2 Move) (string, bool, TurnHits) {
+	before1, before2 := *p1, *p2
+	res, end, hits := playTurn(p1, p2, m1, m2, nil, sharedDice{})
+	learn(&before1, &before2, m2, p1, p2, end)
+	return res, end, hits
+}
+
+// learn lets the AI playing p2 learn from the move m2 it played against p1
+// when training, next1 and next2 being the characters after the turn
+func learn(p1, p2 *Class, m2 Move, next1, next2 *Class, end bool) {
+	if l, ok := baseAgent(p1.PlayerName).(Learner); ok && Train {
+		key := matchKey(p1, p2)
+		l.Observe(key, p1, p2, m2, next1, next2, end)
+		err := l.Save()
+		if err != nil {
+			panic(err)
+		}
 	}
+}
+
+// resolveTurn applies the outcome of p1 executing move m1 and p2
+// executing m2 rolling with dice d and returns a description of the turn
+// and whether the game ended
+func resolveTurn(p1, p2 *Class, m1, m2 Move, d Dice) (string, bool) {
+	res, end, _ := playTurn(p1, p2, m1, m2, nil, d)
+	return res, end
+}
+
+// playTurn resolves a turn like resolveTurn and also returns how the attacks
+// of both characters landed. If item is set p1 uses it in place of m1,
+// spending no stamina and leaving itself open to the attack of p2
+func playTurn(p1, p2 *Class, m1, m2 Move, item *ItemDef, d Dice) (string,
+	bool, TurnHits) {
 	var res string
-	def1, a1 := parseMove(p1, p2, m1)
-	def2, a2 := parseMove(p2, p1, m2)
-	if !def1 && !def2 {
-		// both players attack, handle damage
-		var s1, s2 bool
-		if a1 > 0 {
-			handleDamage(p2, a1)
-			s1 = true
-		}
-		if a2 > 0 {
-			handleDamage(p1, a2)
-			s2 = true
-		}
-		res += printTurn(p1, p2, m1, m2, s1, s2)
-		if s1 {
-			res += fmt.Sprintf("%s deals %d damage\n", p1.PlayerName, a1)
-		}
-		if s2 {
-			res += fmt.Sprintf("%s deals %d damage\n", p2.PlayerName, a2)
-		}
-	} else if !def1 && def2 {
-		// if player2 defends then use attack of player1 to
-		// determine the outcome
-		switch m2 {
-		case BLOCK:
-			// if p2 success, repair armor of p2, else p2
-			// takes damage of p1
-			if a2 > 0 {
-				p2.Armor += a2
-				if p2.Armor > 20 {
-					p2.Armor = 20
-				}
-				res += printTurn(p1, p2, m1, m2, false, true)
-				res += fmt.Sprintf("%s repairs armor for %d points\n",
-					p2.PlayerName, a2)
-			} else if a1 > 0 {
-				handleDamage(p2, a1)
-				res += printTurn(p1, p2, m1, m2, true, false)
-				res += fmt.Sprintf("%s deals %d damage\n", p1.PlayerName, a1)
-			} else {
-				res += printTurn(p1, p2, m1, m2, false, false)
-			}
-		case PARRY:
-			// if p2 success, p1 takes damage of p1 attack +
-			// damage of p2 counter, vise versa if fail
-			if a2 > 0 {
-				handleDamage(p1, a1+a2)
-				res += printTurn(p1, p2, m1, m2, false, true)
-				res += fmt.Sprintf("%s deals %d damage\n", p2.PlayerName, a1+a2)
-			} else if a1-a2 > 0 {
-				handleDamage(p2, a1-a2)
-				res += printTurn(p1, p2, m1, m2, true, false)
-				res += fmt.Sprintf("%s deals %d damage\n", p1.PlayerName, a1-a2)
-			} else {
-				res += printTurn(p1, p2, m1, m2, false, false)
-			}
-		case EVADE:
-			// if p2 success p2 heals a little, on fail p2
-			// takes damage of p1 attack
-			if a2 > 0 {
-				p2.Health += a2
-				if p2.Health > 100 {
-					p2.Health = 100
-				}
-				res += printTurn(p1, p2, m1, m2, false, true)
-				res += fmt.Sprintf("%s heals %d damage\n", p2.PlayerName, a2)
-			} else if a1 > 0 {
-				handleDamage(p2, a1)
-				res += printTurn(p1, p2, m1, m2, true, false)
-				res += fmt.Sprintf("%s deals %d damage\n", p1.PlayerName, a1)
-			} else {
-				res += printTurn(p1, p2, m1, m2, false, false)
-			}
-		}
-	} else if def1 && !def2 {
-		// if player1 defends then use attack of player2 to
-		// determine the outcome
-		switch m1 {
-		case BLOCK:
-			// if p1 success, repair armor of p1, else p1
-			// takes damage of p2
-			if a1 > 0 {
-				p1.Armor += a1
-				if p1.Armor > 20 {
-					p1.Armor = 20
-				}
-				res += printTurn(p1, p2, m1, m2, true, false)
-				res += fmt.Sprintf("%s repairs armor for %d points\n",
-					p1.PlayerName, a1)
-			} else if a2 > 0 {
-				handleDamage(p1, a2)
-				res += printTurn(p1, p2, m1, m2, false, true)
-				res += fmt.Sprintf("%s deals %d damage\n", p2.PlayerName, a2)
-			} else {
-				res += printTurn(p1, p2, m1, m2, false, false)
-			}
-		case PARRY:
-			// if p1 success, p2 takes damage of p2 attack +
-			// damage of p1 counter, vise versa if fail
-			if a1 > 0 {
-				handleDamage(p2, a2+a1)
-				res += printTurn(p1, p2, m1, m2, true, false)
-				res += fmt.Sprintf("%s deals %d damage\n", p1.PlayerName, a2+a1)
-			} else if a2-a1 > 0 {
-				handleDamage(p1, a2-a1)
-				res += printTurn(p1, p2, m1, m2, false, true)
-				res += fmt.Sprintf("%s deals %d damage\n", p2.PlayerName, a2-a1)
-			} else {
-				res += printTurn(p1, p2, m1, m2, false, false)
-			}
-		case EVADE:
-			// if p1 success p1 heals a little, on fail p1
-			// takes damage of p2 attack
-			if a1 > 0 {
-				p1.Health += a1
-				if p1.Health > 100 {
-					p1.Health = 100
-				}
-				res += printTurn(p1, p2, m1, m2, true, false)
-				res += fmt.Sprintf("%s heals %d damage\n", p1.PlayerName, a1)
-			} else if a2 > 0 {
-				handleDamage(p1, a2)
-				res += printTurn(p1, p2, m1, m2, false, true)
-				res += fmt.Sprintf("%s deals %d damage\n", p2.PlayerName, a2)
-			} else {
-				res += printTurn(p1, p2, m1, m2, false, false)
-			}
-		}
-	} else if def1 && def2 {
-		// nothing happens both players tried to defend
-		res += printTurn(p1, p2, m1, m2, false, false)
-		res += fmt.Sprintf("Nothing happens!\n")
+	var hits TurnHits
+	res += tickEffects(p1)
+	res += tickEffects(p2)
+
+	// stunned characters fail to block whatever move they chose
+	stunned1, stunned2 := p1.has(STUN), p2.has(STUN)
+	if stunned1 {
+		m1 = BLOCK
+	}
+	if stunned2 {
+		m2 = BLOCK
+	}
+	var a1 int
+	var k1 hitKind
+	if item == nil {
+		a1, k1 = parseMove(p1, p2, m1, d)
+	}
+	a2, k2 := parseMove(p2, p1, m2, d)
+	if !stunned1 && item == nil {
+		p1.spendStamina(m1)
+	}
+	if !stunned2 {
+		p2.spendStamina(m2)
+	}
+
+	// the outcomes of both moves decide the rule resolving the turn, an
+	// item restores p1 before the attack of p2 lands and puts its effect
+	// on like a move
+	md1, md2 := MoveOf(p1, m1), MoveOf(p2, m2)
+	if item != nil {
+		md1 = MoveDef{Name: item.Name, Outcome: itemOutcome,
+			Effect: item.Effect}
+		res += consume(p1, *item)
+	}
+	s1, s2, text := resolve(side{p1, md1, a1, k1, &hits.Damage1},
+		side{p2, md2, a2, k2, &hits.Damage2})
+	res += printTurn(p1, p2, md1, md2, s1, s2) + text
+	if hits.Damage1 > 0 {
+		hits.Kind1 = k1.label()
+	}
+	if hits.Damage2 > 0 {
+		hits.Kind2 = k2.label()
+	}
+
+	expireEffects(p1)
+	expireEffects(p2)
+	if s1 || item != nil {
+		res += applyMoveEffect(p1, p2, md1, d)
+	}
+	if s2 {
+		res += applyMoveEffect(p2, p1, md2, d)
 	}
 
 	var end bool
@@ -397,10 +276,5 @@ func Turn(p1, p2 *Class, m1, m2 Move) (string, bool) {
 
 	res += "------------------------\n"
 
-	if AI_ALG == AI_REINFORCEMENT {
-		nextState := getState(p1, p2)
-		updateQT(state, nextState, m2)
-	}
-
-	return res, end
+	return res, end, hits
 }
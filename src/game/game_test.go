@@ -44,7 +44,7 @@ func TestNewKnight(t *testing.T) {
 	var hs, ss, as [][]interface{}
 	var strs, dexs, ints [][]interface{}
 	for i := 0; i < nClassTests; i++ {
-		c := NewKnight("Knight")
+		c := mustClass("Knight", "Knight")
 
 		if c.PlayerName != "Knight" {
 			pns = append(pns, []interface{}{c.PlayerName, i})
@@ -55,6 +55,9 @@ func TestNewKnight(t *testing.T) {
 		if c.Health < 80 || c.Health > 100 {
 			hs = append(hs, []interface{}{c.Health, i})
 		}
+		if c.Stamina < 50 || c.Stamina > 70 || c.MaxStamina != c.Stamina {
+			ss = append(ss, []interface{}{c.Stamina, i})
+		}
 		if c.Armor < 0 || c.Armor > 20 {
 			as = append(as, []interface{}{c.Armor, i})
 		}
@@ -78,7 +81,7 @@ func TestNewArcher(t *testing.T) {
 	var hs, ss, as [][]interface{}
 	var strs, dexs, ints [][]interface{}
 	for i := 0; i < nClassTests; i++ {
-		c := NewArcher("Archer")
+		c := mustClass("Archer", "Archer")
 
 		if c.PlayerName != "Archer" {
 			pns = append(pns, []interface{}{c.PlayerName, i})
@@ -89,6 +92,9 @@ func TestNewArcher(t *testing.T) {
 		if c.Health < 80 || c.Health > 100 {
 			hs = append(hs, []interface{}{c.Health, i})
 		}
+		if c.Stamina < 50 || c.Stamina > 70 || c.MaxStamina != c.Stamina {
+			ss = append(ss, []interface{}{c.Stamina, i})
+		}
 		if c.Armor < 0 || c.Armor > 20 {
 			as = append(as, []interface{}{c.Armor, i})
 		}
@@ -112,7 +118,7 @@ func TestNewWizard(t *testing.T) {
 	var hs, ss, as [][]interface{}
 	var strs, dexs, ints [][]interface{}
 	for i := 0; i < nClassTests; i++ {
-		c := NewWizard("Wizard")
+		c := mustClass("Wizard", "Wizard")
 
 		if c.PlayerName != "Wizard" {
 			pns = append(pns, []interface{}{c.PlayerName, i})
@@ -123,6 +129,9 @@ func TestNewWizard(t *testing.T) {
 		if c.Health < 80 || c.Health > 100 {
 			hs = append(hs, []interface{}{c.Health, i})
 		}
+		if c.Stamina < 50 || c.Stamina > 70 || c.MaxStamina != c.Stamina {
+			ss = append(ss, []interface{}{c.Stamina, i})
+		}
 		if c.Armor < 0 || c.Armor > 20 {
 			as = append(as, []interface{}{c.Armor, i})
 		}
@@ -138,3 +147,13 @@ func TestNewWizard(t *testing.T) {
 	}
 	printClassErrs(pns, cns, hs, ss, as, strs, dexs, ints, t)
 }
+
+// mustClass generates a character of the class called className, panicking
+// if there is no such class
+func mustClass(className, playerName string) Class {
+	c, err := NewClass(className, playerName)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
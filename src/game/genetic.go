@@ -0,0 +1,148 @@
+package game
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// genetic algorithm settings
+const (
+	nElite         = 2   // best genomes copied unchanged into each generation
+	tournamentSize = 3   // genomes competing to be selected as a parent
+	mutationRate   = .2  // chance of mutating each gene
+	mutationScale  = .25 // standard deviation of mutations
+	maxSharpness   = 4
+)
+
+// genome is a minmax profile together with its fitness
+type genome struct {
+	prof    MinMaxProfile
+	fitness float32
+}
+
+// genes returns pointers to every tunable weight of the profile
+func (prof *MinMaxProfile) genes() []*float32 {
+	var genes []*float32
+	for i := range prof.Tables {
+		genes = append(genes, &prof.Tables[i])
+	}
+	for i := range prof.Moves {
+		genes = append(genes, &prof.Moves[i])
+	}
+	return append(genes, &prof.Sharpness)
+}
+
+// randomProfile returns a profile with uniformly random weights
+func randomProfile() MinMaxProfile {
+	prof := DefaultProfile()
+	for _, g := range prof.genes() {
+		*g = rand.Float32() * 2
+	}
+	return prof
+}
+
+// fitness returns the score of prof against every reference agent, wins count
+// 1 and draws count half
+func fitness(prof MinMaxProfile, refs []Agent, matches int) float32 {
+	a := NewMinMaxAgent(prof)
+
+	var score float32
+	for _, ref := range refs {
+		for i := 0; i < matches; i++ {
+			switch PlayMatch(a, frozenAgent{ref}, "") {
+			case 1:
+				score += 1
+			case 0:
+				score += .5
+			}
+		}
+	}
+	return score / float32(len(refs)*matches)
+}
+
+// evaluatePopulation scores every genome in parallel
+func evaluatePopulation(pop []genome, refs []Agent, matches int) {
+	var wg sync.WaitGroup
+	for i := range pop {
+		wg.Add(1)
+		go func(g *genome) {
+			defer wg.Done()
+			g.fitness = fitness(g.prof, refs, matches)
+		}(&pop[i])
+	}
+	wg.Wait()
+
+	sort.Slice(pop, func(i, j int) bool {
+		return pop[i].fitness > pop[j].fitness
+	})
+}
+
+// tournament selects the fittest of tournamentSize random genomes
+func tournament(pop []genome) MinMaxProfile {
+	best := pop[rand.Intn(len(pop))]
+	for i := 1; i < tournamentSize; i++ {
+		g := pop[rand.Intn(len(pop))]
+		if g.fitness > best.fitness {
+			best = g
+		}
+	}
+	return best.prof
+}
+
+// breed creates a child of two profiles using uniform crossover followed by
+// gaussian mutation
+func breed(a, b MinMaxProfile) MinMaxProfile {
+	child := a
+	childGenes := child.genes()
+	bGenes := b.genes()
+	for i, g := range childGenes {
+		if rand.Intn(2) == 0 {
+			*g = *bGenes[i]
+		}
+		if rand.Float32() < mutationRate {
+			*g += float32(rand.NormFloat64() * mutationScale)
+		}
+		if *g < 0 {
+			*g = 0
+		}
+	}
+	if child.Sharpness > maxSharpness {
+		child.Sharpness = maxSharpness
+	}
+	return child
+}
+
+// EvolveMinMax runs a genetic algorithm over minmax profiles, scoring each by
+// playing matches against every reference agent. report is called with the
+// best profile and its fitness after every generation. The best profile found
+// is returned
+func EvolveMinMax(
+	refs []Agent, generations, population, matches int,
+	report func(generation int, best MinMaxProfile, fitness float32),
+) MinMaxProfile {
+	// seed the population with the default profile so it can only improve
+	pop := make([]genome, population)
+	pop[0].prof = DefaultProfile()
+	for i := 1; i < population; i++ {
+		pop[i].prof = randomProfile()
+	}
+	evaluatePopulation(pop, refs, matches)
+
+	for gen := 1; gen <= generations; gen++ {
+		next := make([]genome, 0, population)
+		for i := 0; i < nElite && i < population; i++ {
+			next = append(next, pop[i])
+		}
+		for len(next) < population {
+			next = append(next, genome{prof: breed(tournament(pop),
+				tournament(pop))})
+		}
+		pop = next
+		evaluatePopulation(pop, refs, matches)
+
+		report(gen, pop[0].prof, pop[0].fitness)
+	}
+
+	return pop[0].prof
+}
@@ -0,0 +1,81 @@
+package game
+
+import "testing"
+
+// TestBreed checks that children keep their weights non-negative and their
+// sharpness capped, taking every gene from one of the parents unless mutated
+func TestBreed(t *testing.T) {
+	var low, high MinMaxProfile
+	for _, g := range high.genes() {
+		*g = 10
+	}
+
+	for i := 0; i < 100; i++ {
+		child := breed(low, high)
+		for j, g := range child.genes() {
+			if *g < 0 {
+				t.Fatalf("Got negative gene %d: %f", j, *g)
+			}
+		}
+		if child.Sharpness > maxSharpness {
+			t.Fatalf("Got sharpness %f above %d", child.Sharpness,
+				maxSharpness)
+		}
+	}
+
+	// genes escaping mutation are copied from a parent
+	child := breed(high, high)
+	var copied int
+	for _, g := range child.genes() {
+		if *g == 10 {
+			copied++
+		}
+	}
+	if copied == 0 {
+		t.Errorf("Child copied no gene of its parents")
+	}
+}
+
+// TestTournament checks that tournaments select the fitter genomes
+func TestTournament(t *testing.T) {
+	pop := []genome{
+		{prof: MinMaxProfile{Name: "weak"}, fitness: .1},
+		{prof: MinMaxProfile{Name: "strong"}, fitness: .9},
+	}
+
+	// the strong genome only loses tournaments it doesn't enter, which
+	// happens 1 in 8 times
+	var strong int
+	for i := 0; i < 1000; i++ {
+		if tournament(pop).Name == "strong" {
+			strong++
+		}
+	}
+	if strong < 800 {
+		t.Errorf("Strong genome won %d of 1000 tournaments", strong)
+	}
+	if tournament(pop[1:]).Name != "strong" {
+		t.Errorf("Single genome lost a tournament")
+	}
+}
+
+// TestEvolveMinMax checks that evolution reports every generation and
+// keeps the weights of the profile it returns non-negative
+func TestEvolveMinMax(t *testing.T) {
+	var gens int
+	best := EvolveMinMax([]Agent{randAgent{}}, 2, 4, 5,
+		func(gen int, prof MinMaxProfile, fitness float32) {
+			gens = gen
+			if fitness < 0 || fitness > 1 {
+				t.Errorf("Got fitness %f out of bounds", fitness)
+			}
+		})
+	if gens != 2 {
+		t.Errorf("Got %d generations reported, expected 2", gens)
+	}
+	for _, g := range best.genes() {
+		if *g < 0 {
+			t.Errorf("Got negative gene %f in the best profile", *g)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package game
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// TurnRecord is one turn of a match against a human player, storing both
+// characters before and after the turn, the moves both sides played and how
+// their attacks landed
+type TurnRecord struct {
+	Match        string
+	Player       Class // human controlled character before the turn
+	AI           Class // AI controlled character before the turn
+	PlayerMove   Move
+	PlayerItem   string // consumable used in place of PlayerMove, if any
+	AIMove       Move
+	PlayerHit    string // hit, crit or glancing, empty if nothing landed
+	AIHit        string
+	PlayerDamage int // damage dealt to the opponent
+	AIDamage     int
+	NextPlayer   Class
+	NextAI       Class
+	End          bool
+	PlayerWon    bool // only meaningful when End is set
+}
+
+// PolicyTable maps an encoded state to the probability of playing each move
+type PolicyTable map[uint32][]float32
+
+// PolicyAgent samples its moves from a policy table, playing randomly in
+// states missing from the table
+type PolicyAgent struct {
+	Policy PolicyTable
+}
+
+var serverPolicy *PolicyAgent
+var serverPolicyLock sync.Mutex
+
+// AppendRecord adds record r as a json line to file fn
+func AppendRecord(fn string, r TurnRecord) error {
+	f, err := os.OpenFile(fn, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(body, '\n'))
+	return err
+}
+
+// LoadRecords reads the records of every .jsonl file in directory dir
+func LoadRecords(dir string) ([]TurnRecord, error) {
+	var records []TurnRecord
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return records, err
+	}
+
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".jsonl") {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return records, err
+		}
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var r TurnRecord
+			err = json.Unmarshal(scanner.Bytes(), &r)
+			if err != nil {
+				f.Close()
+				return records, err
+			}
+			records = append(records, r)
+		}
+		f.Close()
+		if err = scanner.Err(); err != nil {
+			return records, err
+		}
+	}
+
+	return records, nil
+}
+
+// wonMatches returns the names of the matches the player won
+func wonMatches(records []TurnRecord) map[string]bool {
+	won := make(map[string]bool)
+	for _, r := range records {
+		if r.End && r.PlayerWon {
+			won[r.Match] = true
+		}
+	}
+	return won
+}
+
+// moveCounts counts the moves played by the human players in every state,
+// seen from the human side so the AI can play their character the same way.
+// Turns spent using items are skipped. When onlyWins is set, only matches won
+// by the player are counted
+func moveCounts(records []TurnRecord, onlyWins bool) map[uint32][]float32 {
+	won := wonMatches(records)
+
+	counts := make(map[uint32][]float32)
+	for _, r := range records {
+		if onlyWins && !won[r.Match] || r.PlayerItem != "" {
+			continue
+		}
+		state := getState(&r.AI, &r.Player)
+		if counts[state] == nil {
+			counts[state] = make([]float32, 6)
+		}
+		counts[state][r.PlayerMove]++
+	}
+	return counts
+}
+
+// ClonePolicy builds a policy table from the moves human players made in
+// records
+func ClonePolicy(records []TurnRecord, onlyWins bool) PolicyTable {
+	policy := make(PolicyTable)
+	for state, counts := range moveCounts(records, onlyWins) {
+		var total float32
+		for _, n := range counts {
+			total += n
+		}
+		probs := make([]float32, len(counts))
+		for i, n := range counts {
+			probs[i] = n / total
+		}
+		policy[state] = probs
+	}
+	return policy
+}
+
+// CloneQTable builds a qtable whose greedy moves are the moves human players
+// made most in records, values are the frequency of each move times scale
+// so further training can refine them
+func CloneQTable(records []TurnRecord, onlyWins bool, scale float32) QTable {
+	qt := make(QTable)
+	for state, probs := range ClonePolicy(records, onlyWins) {
+		row := qt.row(state)
+		for i, p := range probs {
+			row[i] = p * scale
+		}
+	}
+	return qt
+}
+
+// Save writes the policy table to file fn
+func (pt PolicyTable) Save(fn string) error {
+	f, err := os.Create(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := gob.NewEncoder(f)
+	return enc.Encode(pt)
+}
+
+// LoadPolicyTable reads a policy table from file fn
+func LoadPolicyTable(fn string) (PolicyTable, error) {
+	pt := make(PolicyTable)
+
+	f, err := os.Open(fn)
+	if err != nil {
+		return pt, err
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(f)
+	err = dec.Decode(&pt)
+	return pt, err
+}
+
+// serverPolicyAgent returns the policy agent used by the server, loading its
+// table on first use
+func serverPolicyAgent() *PolicyAgent {
+	serverPolicyLock.Lock()
+	defer serverPolicyLock.Unlock()
+
+	if serverPolicy == nil {
+		pt, err := LoadPolicyTable("policy")
+		if err != nil && !os.IsNotExist(err) {
+			panic(err)
+		}
+		serverPolicy = &PolicyAgent{pt}
+	}
+	return serverPolicy
+}
+
+// GetTurn samples a move from the policy of the current state
+func (a *PolicyAgent) GetTurn(p, e *Class) Move {
+	probs, ok := a.Policy[getState(p, e)]
+	if !ok {
+		return getTurnRand()
+	}
+
+	r := rand.Float32()
+	for i, v := range probs {
+		r -= v
+		if r <= 0 {
+			return Move(i)
+		}
+	}
+	return Move(len(probs) - 1)
+}
+
+// Scores returns the probability of every move in the current state
+func (a *PolicyAgent) Scores(p, e *Class) []float32 {
+	probs, ok := a.Policy[getState(p, e)]
+	if !ok {
+		return make([]float32, 6)
+	}
+	return append([]float32(nil), probs...)
+}
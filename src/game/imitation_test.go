@@ -0,0 +1,36 @@
+package game
+
+import "testing"
+
+// TestClonePolicy checks that cloned policies follow the moves humans played
+// and that onlyWins ignores lost matches
+func TestClonePolicy(t *testing.T) {
+	p := mustClass("Archer", "Archer")
+	e := mustClass("Knight", "Knight")
+	records := []TurnRecord{
+		{Match: "won", Player: p, AI: e, PlayerMove: PARRY},
+		{Match: "won", Player: p, AI: e, PlayerMove: PARRY},
+		{Match: "won", Player: p, AI: e, PlayerMove: PARRY, End: true,
+			PlayerWon: true},
+		{Match: "lost", Player: p, AI: e, PlayerMove: HEAVY, End: true},
+	}
+	state := getState(&e, &p)
+
+	policy := ClonePolicy(records, false)
+	if policy[state][PARRY] != .75 || policy[state][HEAVY] != .25 {
+		t.Errorf("Got policy %v, expected .75 parry and .25 heavy",
+			policy[state])
+	}
+
+	policy = ClonePolicy(records, true)
+	if policy[state][PARRY] != 1 {
+		t.Errorf("Got policy %v from won matches, expected only parry",
+			policy[state])
+	}
+
+	qt := CloneQTable(records, false, 2)
+	if bestMove(qt.get(state)) != PARRY || qt.get(state)[PARRY] != 1.5 {
+		t.Errorf("Got qtable row %v, expected parry valued 1.5",
+			qt.get(state))
+	}
+}
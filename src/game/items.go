@@ -0,0 +1,416 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// ITEMS_FILE is the file the item registry is loaded from by default
+const ITEMS_FILE = "items.json"
+
+// maxItems is the number of items a character can carry
+const maxItems = 8
+
+// kinds of items
+const (
+	WEAPON     = "weapon"     // equipped, raises attributes and attack damage
+	GEAR       = "armor"      // equipped, raises attributes, health and armor
+	CONSUMABLE = "consumable" // used up during a match for a one-shot effect
+)
+
+// itemOutcome is the outcome of a character using a consumable in place of
+// a move, which leaves it open to the attack of its opponent
+const itemOutcome = "item"
+
+// ItemDef defines an item characters can find after victories. Equipped
+// items add their bonuses to the stats of the character wearing them, and
+// consumables restore health, armor and stamina or apply their effect once
+type ItemDef struct {
+	Name      string
+	Kind      string  // WEAPON, GEAR or CONSUMABLE
+	Strength  float32 `json:",omitempty"` // attribute bonuses when equipped
+	Dexterity float32 `json:",omitempty"`
+	Intellect float32 `json:",omitempty"`
+	Damage    float32 `json:",omitempty"` // share of attack damage added
+	Health    int     `json:",omitempty"` // max health added or health healed
+	Armor     int     `json:",omitempty"` // max armor added or armor repaired
+	Stamina   int     `json:",omitempty"` // stamina restored
+	Cleanse   bool    `json:",omitempty"` // removes bleed, burn and stun
+
+	// put on the user if a shield and otherwise on its opponent
+	Effect *EffectSpec `json:",omitempty"`
+}
+
+// items is the item registry, the pool items are dropped from
+var items = DefaultItems()
+
+// DefaultItems returns the weapons, armor pieces and consumables which are
+// also shipped in ITEMS_FILE
+func DefaultItems() []ItemDef {
+	return []ItemDef{
+		{Name: "Iron Sword", Kind: WEAPON, Strength: .05, Damage: .1},
+		{Name: "Hunting Bow", Kind: WEAPON, Dexterity: .05, Damage: .1},
+		{Name: "Oak Staff", Kind: WEAPON, Intellect: .05, Damage: .1},
+		{Name: "Warhammer", Kind: WEAPON, Strength: .1, Dexterity: -.05,
+			Damage: .2},
+		{Name: "Leather Vest", Kind: GEAR, Dexterity: .05, Armor: 3},
+		{Name: "Chainmail", Kind: GEAR, Dexterity: -.05, Armor: 6},
+		{Name: "Silk Robe", Kind: GEAR, Intellect: .05, Health: 10},
+		{Name: "Health Potion", Kind: CONSUMABLE, Health: 25},
+		{Name: "Repair Kit", Kind: CONSUMABLE, Armor: 10},
+		{Name: "Stamina Tonic", Kind: CONSUMABLE, Stamina: 30},
+		{Name: "Smelling Salts", Kind: CONSUMABLE, Cleanse: true},
+		{Name: "Ward Scroll", Kind: CONSUMABLE,
+			Effect: &EffectSpec{Kind: "shielded", Turns: 3, Power: 8}},
+		{Name: "Fire Bomb", Kind: CONSUMABLE,
+			Effect: &EffectSpec{Kind: "burn", Turns: 2, Power: 4}},
+	}
+}
+
+// LoadItems replaces the item registry with the items in file fn
+func LoadItems(fn string) error {
+	body, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return err
+	}
+
+	var defs []ItemDef
+	err = json.Unmarshal(body, &defs)
+	if err != nil {
+		return err
+	}
+
+	err = validateItems(defs)
+	if err != nil {
+		return fmt.Errorf("%s: %v", fn, err)
+	}
+	items = defs
+	return nil
+}
+
+// validateItems checks that defs can be used as the item registry
+func validateItems(defs []ItemDef) error {
+	if len(defs) == 0 {
+		return errors.New("No items defined")
+	}
+
+	seen := make(map[string]bool)
+	for i, def := range defs {
+		if def.Name == "" {
+			return fmt.Errorf("Item %d has no name", i)
+		}
+		if seen[def.Name] {
+			return fmt.Errorf("Item %s defined twice", def.Name)
+		}
+		seen[def.Name] = true
+
+		err := def.validate()
+		if err != nil {
+			return fmt.Errorf("Item %s %v", def.Name, err)
+		}
+	}
+	return nil
+}
+
+// validate checks that the item has a known kind and only the bonuses its
+// kind can have, within sensible bounds
+func (def ItemDef) validate() error {
+	equipped := def.Strength != 0 || def.Dexterity != 0 ||
+		def.Intellect != 0 || def.Damage != 0
+	used := def.Stamina != 0 || def.Cleanse || def.Effect != nil
+
+	switch def.Kind {
+	case WEAPON, GEAR:
+		if used {
+			return errors.New("is equipped but has consumable effects")
+		}
+		if def.Kind == WEAPON && (def.Health != 0 || def.Armor != 0) {
+			return errors.New("is a weapon with health or armor")
+		}
+		if def.Kind == GEAR && def.Damage != 0 {
+			return errors.New("is armor with damage")
+		}
+	case CONSUMABLE:
+		if equipped {
+			return errors.New("is consumed but has equipment bonuses")
+		}
+	default:
+		return fmt.Errorf("has unknown kind %s", def.Kind)
+	}
+
+	for _, b := range []float32{def.Strength, def.Dexterity, def.Intellect} {
+		if b < -.25 || b > .25 {
+			return errors.New("has attribute bonuses out of bounds")
+		}
+	}
+	if def.Damage < 0 || def.Damage > 1 || def.Health < 0 ||
+		def.Health > 50 || def.Armor < 0 || def.Armor > 20 ||
+		def.Stamina < 0 || def.Stamina > 100 {
+		return errors.New("has bonuses out of bounds")
+	}
+	if def.Effect != nil {
+		if err := def.Effect.validate(); err != nil {
+			return fmt.Errorf("has an invalid effect: %v", err)
+		}
+	}
+	return nil
+}
+
+// Items returns the item registry
+func Items() []ItemDef {
+	return items
+}
+
+// LookupItem returns the definition of the item called name
+func LookupItem(name string) (ItemDef, bool) {
+	for _, def := range items {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return ItemDef{}, false
+}
+
+// equipment returns the items c has equipped
+func (c *Class) equipment() []ItemDef {
+	var defs []ItemDef
+	for _, name := range []string{c.Weapon, c.Gear} {
+		if def, ok := LookupItem(name); ok {
+			defs = append(defs, def)
+		}
+	}
+	return defs
+}
+
+// gear returns the bonuses of all items c has equipped added up
+func (c *Class) gear() ItemDef {
+	var sum ItemDef
+	for _, def := range c.equipment() {
+		sum.Strength += def.Strength
+		sum.Dexterity += def.Dexterity
+		sum.Intellect += def.Intellect
+		sum.Damage += def.Damage
+		sum.Health += def.Health
+		sum.Armor += def.Armor
+	}
+	return sum
+}
+
+// attribute returns the bonus of the item to the attribute called name
+func (def ItemDef) attribute(name string) float32 {
+	switch name {
+	case "Strength":
+		return def.Strength
+	case "Dexterity":
+		return def.Dexterity
+	case "Intellect":
+		return def.Intellect
+	}
+	return 0
+}
+
+// EquipmentText describes the items c has equipped
+func (c *Class) EquipmentText() string {
+	switch {
+	case c.Weapon != "" && c.Gear != "":
+		return c.Weapon + ", " + c.Gear
+	case c.Weapon != "":
+		return c.Weapon
+	case c.Gear != "":
+		return c.Gear
+	}
+	return "none"
+}
+
+// slotOf returns the equipment slot of c holding items of kind
+func (c *Class) slotOf(kind string) *string {
+	if kind == WEAPON {
+		return &c.Weapon
+	}
+	return &c.Gear
+}
+
+// addItem puts the item called name in the inventory of c, returning false
+// if the inventory is full
+func addItem(c *Class, name string) bool {
+	for i := range c.Inventory {
+		if c.Inventory[i] == "" {
+			c.Inventory[i] = name
+			return true
+		}
+	}
+	return false
+}
+
+// takeItem removes the item called name from the inventory of c, returning
+// false if c doesn't carry it
+func takeItem(c *Class, name string) bool {
+	for i := range c.Inventory {
+		if c.Inventory[i] == name {
+			c.Inventory[i] = ""
+			return true
+		}
+	}
+	return false
+}
+
+// applyItem adds the bonuses of equipped item def to c, or removes them if
+// sign is -1
+func applyItem(c *Class, def ItemDef, sign float32) {
+	c.Strength += sign * def.Strength
+	c.Dexterity += sign * def.Dexterity
+	c.Intellect += sign * def.Intellect
+	c.MaxHealth += int(sign) * def.Health
+	c.MaxArmor += int(sign) * def.Armor
+}
+
+// Equip equips the weapon or armor piece called name from the inventory of
+// c, putting back whatever was equipped in its slot
+func Equip(c *Class, name string) error {
+	def, ok := LookupItem(name)
+	if !ok || def.Kind == CONSUMABLE {
+		return errors.New("Could not equip " + name)
+	}
+	if !takeItem(c, name) {
+		return errors.New(c.PlayerName + " doesn't carry " + name)
+	}
+
+	fillMax(c)
+	if *c.slotOf(def.Kind) != "" {
+		Unequip(c, def.Kind)
+	}
+	*c.slotOf(def.Kind) = name
+	applyItem(c, def, 1)
+	return nil
+}
+
+// Unequip puts the item c has equipped in the slot of kind back in its
+// inventory
+func Unequip(c *Class, kind string) error {
+	slot := c.slotOf(kind)
+	if *slot == "" {
+		return errors.New("Nothing equipped")
+	}
+	if !addItem(c, *slot) {
+		return errors.New("Inventory is full")
+	}
+
+	if def, ok := LookupItem(*slot); ok {
+		applyItem(c, def, -1)
+	}
+	*slot = ""
+	if c.Health > c.HealthCap() {
+		c.Health = c.HealthCap()
+	}
+	if c.Armor > c.ArmorCap() {
+		c.Armor = c.ArmorCap()
+	}
+	return nil
+}
+
+// ItemTurn plays a turn in which c uses the consumable called name in place
+// of a move while e plays m. It returns a description of the turn, whether
+// the game ended and how the attack of e landed. When training the AI learns
+// from the move m it played
+func ItemTurn(c, e *Class, name string, m Move) (string, bool, TurnHits,
+	error) {
+	if err := CanUseItem(c, name); err != nil {
+		return "", false, TurnHits{}, err
+	}
+	before1, before2 := *c, *e
+	def, _ := takeConsumable(c, name)
+	res, end, hits := playTurn(c, e, BLOCK, m, &def, sharedDice{})
+	learn(&before1, &before2, m, c, e, end)
+	return res, end, hits, nil
+}
+
+// CanUseItem returns why c can't use the consumable called name in place of
+// a move, nil if it can
+func CanUseItem(c *Class, name string) error {
+	def, ok := LookupItem(name)
+	if !ok || def.Kind != CONSUMABLE {
+		return errors.New("Could not use " + name)
+	}
+	for _, n := range c.Inventory {
+		if n == name {
+			if c.has(STUN) {
+				return errors.New(c.PlayerName + " is stunned")
+			}
+			return nil
+		}
+	}
+	return errors.New(c.PlayerName + " doesn't carry " + name)
+}
+
+// takeConsumable takes the consumable called name out of the inventory of c
+// and returns its definition
+func takeConsumable(c *Class, name string) (ItemDef, error) {
+	def, ok := LookupItem(name)
+	if !ok || def.Kind != CONSUMABLE {
+		return ItemDef{}, errors.New("Could not use " + name)
+	}
+	if !takeItem(c, name) {
+		return ItemDef{}, errors.New(c.PlayerName + " doesn't carry " + name)
+	}
+	return def, nil
+}
+
+// consume restores c by consumable def, leaving its effect to the caller,
+// and returns a description of it
+func consume(c *Class, def ItemDef) string {
+	res := fmt.Sprintf("%s uses %s\n", c.PlayerName, def.Name)
+	if def.Health > 0 {
+		c.Health += def.Health
+		if c.Health > c.HealthCap() {
+			c.Health = c.HealthCap()
+		}
+		res += fmt.Sprintf("%s heals %d damage\n", c.PlayerName, def.Health)
+	}
+	if def.Armor > 0 {
+		c.Armor += def.Armor
+		if c.Armor > c.ArmorCap() {
+			c.Armor = c.ArmorCap()
+		}
+		res += fmt.Sprintf("%s repairs armor for %d points\n", c.PlayerName,
+			def.Armor)
+	}
+	if def.Stamina > 0 && c.MaxStamina > 0 {
+		c.Stamina += def.Stamina
+		if c.Stamina > c.MaxStamina {
+			c.Stamina = c.MaxStamina
+		}
+		res += fmt.Sprintf("%s recovers %d stamina\n", c.PlayerName,
+			def.Stamina)
+	}
+	if def.Cleanse {
+		for _, k := range []EffectKind{BLEED, BURN, STUN} {
+			c.Effects[k] = Effect{}
+		}
+		res += fmt.Sprintf("%s is cleansed\n", c.PlayerName)
+	}
+	return res
+}
+
+// dropItem drops a random item from the registry into the inventory of c
+// rolling with dice d and returns a description of it
+func dropItem(c *Class, d Dice) string {
+	def := items[d.Intn(len(items))]
+	if !addItem(c, def.Name) {
+		return fmt.Sprintf("%s finds %s but can't carry more\n",
+			c.PlayerName, def.Name)
+	}
+	return fmt.Sprintf("%s finds %s\n", c.PlayerName, def.Name)
+}
+
+// InventoryItems returns the definitions of the items c carries
+func InventoryItems(c *Class) []ItemDef {
+	var defs []ItemDef
+	for _, name := range c.Inventory {
+		if def, ok := LookupItem(name); ok {
+			defs = append(defs, def)
+		}
+	}
+	return defs
+}
@@ -0,0 +1,244 @@
+package game
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestShippedItems checks that the shipped item file holds the default items
+func TestShippedItems(t *testing.T) {
+	defer func() { items = DefaultItems() }()
+
+	err := LoadItems("../" + ITEMS_FILE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(items, DefaultItems()) {
+		t.Errorf("Got items %v, expected the default items", items)
+	}
+}
+
+// TestLoadItemsInvalid checks that invalid item files are rejected
+func TestLoadItemsInvalid(t *testing.T) {
+	defer func() { items = DefaultItems() }()
+
+	cases := map[string]string{
+		"empty": `[]`,
+		"twice": `[{"Name": "A", "Kind": "weapon", "Damage": 0.1},` +
+			` {"Name": "A", "Kind": "armor", "Armor": 2}]`,
+		"kind":    `[{"Name": "A", "Kind": "ring", "Strength": 0.1}]`,
+		"bonus":   `[{"Name": "A", "Kind": "weapon", "Strength": 0.5}]`,
+		"weapon":  `[{"Name": "A", "Kind": "weapon", "Armor": 5}]`,
+		"equip":   `[{"Name": "A", "Kind": "armor", "Stamina": 10}]`,
+		"consume": `[{"Name": "A", "Kind": "consumable", "Damage": 0.1}]`,
+		"effect": `[{"Name": "A", "Kind": "consumable", "Effect":` +
+			` {"Kind": "frozen", "Turns": 2}}]`,
+	}
+	for name, body := range cases {
+		f, err := ioutil.TempFile("", "items")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(f.Name())
+		f.WriteString(body)
+		f.Close()
+
+		if LoadItems(f.Name()) == nil {
+			t.Errorf("Loaded invalid item file %s", name)
+		}
+	}
+	if len(items) != len(DefaultItems()) {
+		t.Errorf("Got %d items after invalid loads, expected %d", len(items),
+			len(DefaultItems()))
+	}
+}
+
+// TestEquip checks that equipped items add their bonuses, swap out whatever
+// was in their slot and take their bonuses back when unequipped
+func TestEquip(t *testing.T) {
+	c := Class{Health: 100, Armor: 26, Strength: .5, Dexterity: .5,
+		MaxHealth: 100, MaxArmor: 20}
+	c.Inventory = [maxItems]string{"Iron Sword", "Warhammer", "Chainmail",
+		"Health Potion"}
+
+	if err := Equip(&c, "Health Potion"); err == nil {
+		t.Errorf("Equipped a consumable")
+	}
+	if err := Equip(&c, "Silk Robe"); err == nil {
+		t.Errorf("Equipped an item c doesn't carry")
+	}
+
+	Equip(&c, "Iron Sword")
+	Equip(&c, "Chainmail")
+	if c.Strength != .55 || c.Dexterity != .45 || c.ArmorCap() != 26 ||
+		c.gear().Damage != .1 {
+		t.Errorf("Got strength %.2f dexterity %.2f armor cap %d damage %.2f,"+
+			" expected .55, .45, 26 and .1", c.Strength, c.Dexterity,
+			c.ArmorCap(), c.gear().Damage)
+	}
+
+	// the warhammer takes the place of the sword, which goes back in the bag
+	Equip(&c, "Warhammer")
+	if c.Weapon != "Warhammer" || !takeItem(&c, "Iron Sword") {
+		t.Errorf("Got weapon %s and inventory %v, expected the sword swapped"+
+			" for the warhammer", c.Weapon, c.Inventory)
+	}
+
+	Unequip(&c, WEAPON)
+	Unequip(&c, GEAR)
+	if c.Strength != .5 || c.Dexterity != .5 || c.MaxArmor != 20 ||
+		c.Armor != 20 || c.EquipmentText() != "none" {
+		t.Errorf("Got strength %.2f dexterity %.2f max armor %d armor %d"+
+			" equipment %s after unequipping, expected .5, .5, 20, 20 and"+
+			" none", c.Strength, c.Dexterity, c.MaxArmor, c.Armor,
+			c.EquipmentText())
+	}
+	if err := Unequip(&c, WEAPON); err == nil {
+		t.Errorf("Unequipped an empty slot")
+	}
+}
+
+// TestAllocateEquipped checks that equipment bonuses don't count towards the
+// cap of attributes
+func TestAllocateEquipped(t *testing.T) {
+	c := Class{Strength: .95, Points: 2, MaxHealth: 100, MaxArmor: 20}
+	c.Inventory[0] = "Iron Sword"
+	Equip(&c, "Iron Sword")
+
+	if err := Allocate(&c, "Strength"); err != nil || c.Strength != 1.05 {
+		t.Errorf("Got strength %.2f with error %v, expected 1.05",
+			c.Strength, err)
+	}
+	if err := Allocate(&c, "Strength"); err == nil {
+		t.Errorf("Allocated a point to a capped attribute")
+	}
+	if attribute(&c, "Strength") != 1 {
+		t.Errorf("Got strength %.2f in rolls, expected 1",
+			attribute(&c, "Strength"))
+	}
+}
+
+// TestUseItem checks the effects of consumables used in place of moves, that
+// they are used up and that the attack of the opponent still lands
+func TestUseItem(t *testing.T) {
+	c := Class{Health: 90, Armor: 5, Stamina: 10, MaxStamina: 100}
+	e := Class{}
+	c.Effects[BLEED] = Effect{Turns: 2, Power: 3}
+	c.Inventory = [maxItems]string{"Smelling Salts", "Health Potion",
+		"Repair Kit", "Stamina Tonic", "Ward Scroll", "Fire Bomb"}
+
+	// the salts stop the bleeding after its first tick, while e fails to
+	// block every turn
+	for _, name := range c.Inventory[:6] {
+		if _, _, _, err := ItemTurn(&c, &e, name, BLOCK); err != nil {
+			t.Errorf("Could not use %s: %v", name, err)
+		}
+	}
+	if c.Health != 100 || c.Armor != 15 || c.Stamina != 40 {
+		t.Errorf("Got %d health %d armor %d stamina, expected 100, 15 and 40",
+			c.Health, c.Armor, c.Stamina)
+	}
+	if c.has(BLEED) || !c.has(SHIELDED) || !e.has(BURN) {
+		t.Errorf("Got effects %v on c and %v on e, expected c shielded and"+
+			" e burning", c.Effects, e.Effects)
+	}
+	if len(InventoryItems(&c)) != 0 {
+		t.Errorf("Got inventory %v, expected every item used up",
+			c.Inventory)
+	}
+
+	if _, _, _, err := ItemTurn(&c, &e, "Health Potion", BLOCK); err == nil {
+		t.Errorf("Used a potion twice")
+	}
+	c.Inventory[0] = "Iron Sword"
+	if _, _, _, err := ItemTurn(&c, &e, "Iron Sword", BLOCK); err == nil {
+		t.Errorf("Used a weapon")
+	}
+
+	// the potion heals 25 while the heavy attack of a strong e can't miss
+	// against no intellect and deals between 1 and 11 damage
+	c = Class{Health: 50, Intellect: 0}
+	e = Class{Strength: .5}
+	c.Inventory[0] = "Health Potion"
+	_, _, hits, err := ItemTurn(&c, &e, "Health Potion", HEAVY)
+	if err != nil || c.Inventory[0] != "" {
+		t.Errorf("Got error %v and inventory %v, expected the potion used",
+			err, c.Inventory)
+	}
+	if hits.Damage2 < 1 || c.Health != 75-hits.Damage2 {
+		t.Errorf("Got %d health after %d damage, expected the potion to"+
+			" heal 25 and the attack to land", c.Health, hits.Damage2)
+	}
+}
+
+// TestItemTurn checks that using an item takes the turn of the character,
+// leaving it open to the attack of its opponent, and that stunned characters
+// can't use items
+func TestItemTurn(t *testing.T) {
+	base := Class{Health: 50, Strength: .5, Dexterity: .5, Intellect: .5,
+		Stamina: 50, MaxStamina: 100}
+	c, e := base, base
+	c.Inventory[0] = "Health Potion"
+	potion, _ := LookupItem("Health Potion")
+
+	// the potion heals 25 before the heavy attack of e lands for 11
+	_, _, hits := playTurn(&c, &e, BLOCK, HEAVY, &potion, fixedDice{.9, 20})
+	if c.Health != 64 || c.Stamina != 50 || hits.Damage2 != 11 {
+		t.Errorf("Got %d health %d stamina and %d damage taken, expected 64,"+
+			" 50 and 11", c.Health, c.Stamina, hits.Damage2)
+	}
+
+	// a block of e against the potion has nothing to defend, while the
+	// potion still heals
+	res, _, _ := playTurn(&c, &e, BLOCK, BLOCK, &potion, fixedDice{.1, 20})
+	if c.Health != 89 || !strings.Contains(res,
+		"has no attack to defend against") ||
+		strings.Contains(res, "Nothing happens") {
+		t.Errorf("Got %d health and turn %q, expected 89 health and e"+
+			" defending against nothing", c.Health, res)
+	}
+
+	c.Effects[STUN] = Effect{Turns: 1}
+	if _, _, _, err := ItemTurn(&c, &e, "Health Potion", BLOCK); err == nil {
+		t.Errorf("Used an item while stunned")
+	}
+	c.Effects[STUN] = Effect{}
+	if _, _, _, err := ItemTurn(&c, &e, "Health Potion", BLOCK); err != nil ||
+		c.Inventory[0] != "" {
+		t.Errorf("Got error %v and inventory %v, expected the potion used",
+			err, c.Inventory)
+	}
+}
+
+// TestItemDrops checks that wins drop items into the inventory until it is
+// full
+func TestItemDrops(t *testing.T) {
+	c := Class{PlayerName: "c"}
+	e := Class{}
+	for i := 0; i < maxItems+1; i++ {
+		AwardMatch(&c, &e, true)
+	}
+	if len(InventoryItems(&c)) != maxItems {
+		t.Errorf("Got %d items after %d wins, expected %d",
+			len(InventoryItems(&c)), maxItems+1, maxItems)
+	}
+}
+
+// TestArmedState checks that getState tells armed characters apart
+func TestArmedState(t *testing.T) {
+	p := mustClass("Knight", "p")
+	e := mustClass("Archer", "e")
+	s := getState(&p, &e)
+
+	p.Weapon = "Iron Sword"
+	armed := getState(&p, &e)
+	e.Weapon = "Hunting Bow"
+	both := getState(&p, &e)
+	if armed == s || both == armed || both == s {
+		t.Errorf("Got states %d, %d and %d, expected three distinct states",
+			s, armed, both)
+	}
+}
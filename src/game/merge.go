@@ -0,0 +1,137 @@
+package game
+
+import (
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Visits counts how many times a reinforcement agent learned from each state
+type Visits map[uint32]int
+
+// MergeSource is a qtable to merge together with its weight and the visit
+// counts it was trained with, nil when they were never recorded
+type MergeSource struct {
+	Table  QTable
+	Visits Visits
+	Weight float32
+}
+
+// Save writes the visit counts to file fn
+func (v Visits) Save(fn string) error {
+	f, err := os.Create(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := gob.NewEncoder(f)
+	return enc.Encode(v)
+}
+
+// LoadVisits reads visit counts from file fn
+func LoadVisits(fn string) (Visits, error) {
+	v := make(Visits)
+
+	f, err := os.Open(fn)
+	if err != nil {
+		return v, err
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(f)
+	err = dec.Decode(&v)
+	return v, err
+}
+
+// LoadMergeSource reads the qtable in fn and the visit counts saved next to
+// it if there are any
+func LoadMergeSource(fn string, weight float32) (MergeSource, error) {
+	src := MergeSource{Weight: weight}
+
+	qt, err := LoadQTable(fn)
+	if err != nil {
+		return src, err
+	}
+	src.Table = qt
+
+	v, err := LoadVisits(fn + "-visits")
+	if err == nil {
+		src.Visits = v
+	} else if !os.IsNotExist(err) {
+		return src, err
+	}
+	return src, nil
+}
+
+// LoadPlayerSources reads every per player qtable in directory dir with
+// weight 1, skipping the second tables, models and visit counts saved next
+// to them
+func LoadPlayerSources(dir string) ([]MergeSource, error) {
+	var srcs []MergeSource
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return srcs, err
+	}
+
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || strings.HasSuffix(name, "-qt2") ||
+			strings.HasSuffix(name, "-model") ||
+			strings.HasSuffix(name, "-visits") {
+			continue
+		}
+		src, err := LoadMergeSource(filepath.Join(dir, name), 1)
+		if err != nil {
+			return srcs, err
+		}
+		srcs = append(srcs, src)
+	}
+	return srcs, nil
+}
+
+// MergeTables averages the values of every state over the sources which have
+// seen it, weighted by the weight of each source. When byVisits is set the
+// weight is also multiplied by the number of visits to the state, sources
+// without recorded visits count every state they have as visited once. The
+// merged table and the summed visits are returned
+func MergeTables(srcs []MergeSource, byVisits bool) (QTable, Visits) {
+	sums := make(QTable)
+	totals := make(map[uint32]float32)
+	visits := make(Visits)
+
+	for _, src := range srcs {
+		for state, values := range src.Table {
+			w := src.Weight
+			if byVisits {
+				n := 1
+				if src.Visits != nil {
+					n = src.Visits[state]
+				}
+				w *= float32(n)
+			}
+			if src.Visits != nil {
+				visits[state] += src.Visits[state]
+			}
+			if w <= 0 {
+				continue
+			}
+
+			row := sums.row(state)
+			for i, v := range values {
+				row[i] += w * v
+			}
+			totals[state] += w
+		}
+	}
+
+	for state, row := range sums {
+		for i := range row {
+			row[i] /= totals[state]
+		}
+	}
+	return sums, visits
+}
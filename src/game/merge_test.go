@@ -0,0 +1,65 @@
+package game
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMergeWeights checks weighted and visit count weighted averaging
+func TestMergeWeights(t *testing.T) {
+	a := MergeSource{Table: QTable{1: {0, 0, 0, 0, 0, 0}}, Weight: 1,
+		Visits: Visits{1: 1}}
+	b := MergeSource{Table: QTable{1: {4, 0, 0, 0, 0, 0},
+		2: {1, 1, 1, 1, 1, 1}}, Weight: 3, Visits: Visits{1: 3, 2: 1}}
+
+	qt, visits := MergeTables([]MergeSource{a, b}, false)
+	if v := qt[1][HEAVY]; v != 3 {
+		t.Errorf("Got weighted value %f, expected 3", v)
+	}
+	if v := qt[2][HEAVY]; v != 1 {
+		t.Errorf("Got value %f of state only b saw, expected 1", v)
+	}
+	if visits[1] != 4 {
+		t.Errorf("Got %d visits, expected 4", visits[1])
+	}
+
+	qt, _ = MergeTables([]MergeSource{a, b}, true)
+	if v := qt[1][HEAVY]; v != 3.6 {
+		t.Errorf("Got visit weighted value %f, expected 3.6", v)
+	}
+}
+
+// TestLoadPlayerSources checks that only the player tables are loaded
+func TestLoadPlayerSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qtables")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	qt := QTable{1: {1, 1, 1, 1, 1, 1}}
+	for _, fn := range []string{"alice", "alice-qt2", "bob", "bob2",
+		"bob-visits"} {
+		if err = qt.Save(filepath.Join(dir, fn)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = Visits{1: 2}.Save(filepath.Join(dir, "bob-visits"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcs, err := LoadPlayerSources(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// bob2 is a player of its own rather than the second table of bob
+	if len(srcs) != 3 {
+		t.Fatalf("Got %d player tables, expected 3", len(srcs))
+	}
+	if srcs[1].Visits[1] != 2 {
+		t.Errorf("Got %d visits for bob, expected 2", srcs[1].Visits[1])
+	}
+}
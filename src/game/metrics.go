@@ -0,0 +1,148 @@
+package game
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"sync"
+)
+
+// METRICS is set by main using cmd line flags and receives the telemetry of
+// new learners, nil disables telemetry
+var METRICS *Telemetry
+
+// MetricRecord is one line of training telemetry. Episode records are written
+// after every match a learner finishes and eval records after every
+// evaluation against the reference agents
+type MetricRecord struct {
+	Kind    string             // "episode" or "eval"
+	Episode int                // episodes finished when the record was made
+	Stage   string             `json:",omitempty"` // curriculum stage
+	Return  float32            `json:",omitempty"` // sum of rewards
+	TDError float32            `json:",omitempty"` // mean absolute td error
+	Epsilon float32            `json:",omitempty"` // explore rate at the end
+	States  int                `json:",omitempty"` // states in the qtable
+	WinRate map[string]float32 `json:",omitempty"` // win rate per reference
+}
+
+// Telemetry accumulates training statistics of learners and writes them as
+// json lines to Out. All methods do nothing on a nil Telemetry
+type Telemetry struct {
+	Out io.Writer
+
+	episodes int
+	returns  map[string]float32
+	tdSum    float64
+	tdCount  int
+	mu       sync.Mutex
+}
+
+// rater is implemented by exploration policies with an explore rate
+type rater interface {
+	Rate() float32
+}
+
+// NewTelemetry returns telemetry writing to out
+func NewTelemetry(out io.Writer) *Telemetry {
+	return &Telemetry{Out: out, returns: make(map[string]float32)}
+}
+
+// reward adds r to the return of the episode of match key
+func (t *Telemetry) reward(key string, r float32) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.returns[key] += r
+}
+
+// tdError records the td error of a single update
+func (t *Telemetry) tdError(d float32) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.tdSum += math.Abs(float64(d))
+	t.tdCount++
+}
+
+// endEpisode writes an episode record for match key. explore is the policy
+// of the learner and states the number of states its qtable has seen
+func (t *Telemetry) endEpisode(key string, explore ExplorePolicy, states int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.episodes++
+	rec := MetricRecord{
+		Kind:    "episode",
+		Episode: t.episodes,
+		Return:  t.returns[key],
+		States:  states,
+	}
+	if t.tdCount > 0 {
+		rec.TDError = float32(t.tdSum / float64(t.tdCount))
+	}
+	if r, ok := explore.(rater); ok {
+		rec.Epsilon = r.Rate()
+	}
+	delete(t.returns, key)
+	t.tdSum, t.tdCount = 0, 0
+
+	t.write(rec)
+}
+
+// Evaluation writes an eval record with the win rate against each reference
+// made while training curriculum stage stage, empty without a curriculum
+func (t *Telemetry) Evaluation(stage string, winRates map[string]float32) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.write(MetricRecord{Kind: "eval", Episode: t.episodes, Stage: stage,
+		WinRate: winRates})
+}
+
+// write encodes rec as a json line. Only call with t.mu held
+func (t *Telemetry) write(rec MetricRecord) {
+	body, err := json.Marshal(rec)
+	if err != nil {
+		panic(err)
+	}
+	_, err = t.Out.Write(append(body, '\n'))
+	if err != nil {
+		panic(err)
+	}
+}
+
+// LoadMetrics reads every record of a telemetry file
+func LoadMetrics(fn string) ([]MetricRecord, error) {
+	var recs []MetricRecord
+
+	f, err := os.Open(fn)
+	if err != nil {
+		return recs, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec MetricRecord
+		err = json.Unmarshal(scanner.Bytes(), &rec)
+		if err != nil {
+			return recs, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, scanner.Err()
+}
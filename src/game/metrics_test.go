@@ -0,0 +1,46 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// TestTelemetry checks that a finished match writes one episode record with
+// its return, td error, explore rate and states seen
+func TestTelemetry(t *testing.T) {
+	var out bytes.Buffer
+	a := NewReinforcement(make(QTable), QLearning{})
+	a.Explore = &ConstantEpsilon{epsilonGreedy{.2}}
+	a.Metrics = NewTelemetry(&out)
+
+	p := &Class{Health: 100, Armor: 20}
+	e := &Class{Health: 100, Armor: 20}
+	hurt := &Class{Health: 20, Armor: 20}
+	a.Observe("m", p, e, HEAVY, hurt, e, false)
+	a.Observe("m", hurt, e, HEAVY, hurt, e, true)
+
+	var rec MetricRecord
+	err := json.Unmarshal(out.Bytes(), &rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reward := a.Reward.reward(getState(p, e), getState(hurt, e))
+	if rec.Kind != "episode" || rec.Episode != 1 {
+		t.Errorf("Got %s record %d, expected episode record 1", rec.Kind,
+			rec.Episode)
+	}
+	if rec.Return != reward {
+		t.Errorf("Got return %f, expected %f", rec.Return, reward)
+	}
+	if rec.TDError <= 0 {
+		t.Errorf("Got td error %f, expected a positive error", rec.TDError)
+	}
+	if rec.Epsilon != .2 {
+		t.Errorf("Got epsilon %f, expected .2", rec.Epsilon)
+	}
+	if rec.States != len(a.QT) || rec.States == 0 {
+		t.Errorf("Got %d states, expected %d", rec.States, len(a.QT))
+	}
+}
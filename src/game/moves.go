@@ -0,0 +1,291 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// MOVES_FILE is the file the move registry is loaded from by default
+const MOVES_FILE = "moves.json"
+
+// outcomes a move can have, attacks go in the first three move slots of a
+// class and defenses in the last three
+const (
+	ATTACK  = "attack"  // damages the opponent
+	REPAIR  = "repair"  // repairs armor when defending an attack
+	COUNTER = "counter" // reflects an attack, backfiring on failure
+	HEAL    = "heal"    // heals when defending an attack
+)
+
+// MoveDef defines a move classes can have in one of their six move slots.
+// Attacks succeed when a roll beats the Success attribute of the opponent and
+// deal up to Base damage scaled by the Damage attribute of the attacker.
+// Defenses succeed when a roll is below their own Success attribute and roll
+// up to Base scaled by their own Damage attribute
+type MoveDef struct {
+	Name    string
+	Outcome string // ATTACK, REPAIR, COUNTER or HEAL
+	Success string // attribute checked for success
+	Damage  string // attribute scaling the amount rolled
+	Base    int    // highest amount rolled before scaling
+	Cost    int    // stamina spent, negative for moves regenerating it
+
+	Pierce bool        `json:",omitempty"` // damage skips shields and armor
+	Effect *EffectSpec `json:",omitempty"` // applied when the move succeeds
+}
+
+// baseMoves are the moves of every slot as they played before classes had
+// moves of their own, used for characters of unknown classes
+var baseMoves = [6]MoveDef{
+	{"Heavy Attack", ATTACK, "Intellect", "Strength", 20, 25, false, nil},
+	{"Quick Attack", ATTACK, "Strength", "Dexterity", 20, 10, false, nil},
+	{"Standard Attack", ATTACK, "Dexterity", "Intellect", 20, 15, false, nil},
+	{"Block", REPAIR, "Strength", "Strength", 10, -15, false, nil},
+	{"Parry", COUNTER, "Dexterity", "Dexterity", 10, -5, false, nil},
+	{"Evade", HEAL, "Intellect", "Intellect", 10, -15, false, nil},
+}
+
+// moves is the move registry
+var moves = DefaultMoves()
+
+// DefaultMoves returns the moves of the default classes which are also
+// shipped in MOVES_FILE. Every class checks, scales, rolls and costs its moves
+// its own way, playing to the attributes it rolls highest
+func DefaultMoves() []MoveDef {
+	return []MoveDef{
+		// knight, strong hits and a sturdy shield but little magic
+		{"Crushing Blow", ATTACK, "Intellect", "Strength", 24, 30, false,
+			&EffectSpec{Kind: "stun", Turns: 1, Chance: .3}},
+		{"Quick Thrust", ATTACK, "Strength", "Dexterity", 16, 10, false, nil},
+		{"Sword Slash", ATTACK, "Dexterity", "Strength", 20, 15, false, nil},
+		{"Shield", REPAIR, "Strength", "Strength", 14, -10, false, nil},
+		{"Counter", COUNTER, "Strength", "Dexterity", 10, -5, false, nil},
+		{"Drink Potion", HEAL, "Intellect", "Strength", 8, -20, false, nil},
+
+		// archer, cheap accurate shots and a quick dagger
+		{"Piercing Shot", ATTACK, "Intellect", "Dexterity", 22, 25, false,
+			&EffectSpec{Kind: "bleed", Turns: 3, Power: 2}},
+		{"Quick Fire", ATTACK, "Strength", "Dexterity", 14, 8, false, nil},
+		{"Long Shot", ATTACK, "Dexterity", "Dexterity", 20, 18, false, nil},
+		{"Block", REPAIR, "Dexterity", "Strength", 8, -15, false, nil},
+		{"Dagger", COUNTER, "Dexterity", "Dexterity", 12, -5, false, nil},
+		{"Apply Bandaid", HEAL, "Intellect", "Intellect", 10, -15, false,
+			nil},
+
+		// wizard, costly spells scaled by intellect
+		{"Lightning", ATTACK, "Intellect", "Intellect", 26, 35, false, nil},
+		{"Arcane Bolt", ATTACK, "Strength", "Intellect", 14, 10, false, nil},
+		{"Fireball", ATTACK, "Dexterity", "Intellect", 18, 20, false,
+			&EffectSpec{Kind: "burn", Turns: 2, Power: 3}},
+		{"Magic Shield", REPAIR, "Intellect", "Intellect", 8, -10, false,
+			&EffectSpec{Kind: "shielded", Turns: 2, Power: 6}},
+		{"Counterspell", COUNTER, "Intellect", "Intellect", 8, -5, false,
+			nil},
+		{"Heal", HEAL, "Intellect", "Intellect", 14, -15, false, nil},
+
+		// rogue, dexterous strikes and the strongest counter
+		{"Ambush", ATTACK, "Intellect", "Dexterity", 22, 25, false, nil},
+		{"Backstab", ATTACK, "Strength", "Dexterity", 16, 12, true,
+			&EffectSpec{Kind: "bleed", Turns: 2, Power: 2}},
+		{"Throwing Knives", ATTACK, "Dexterity", "Strength", 18, 12, false,
+			nil},
+		{"Cloak", REPAIR, "Dexterity", "Dexterity", 8, -20, false, nil},
+		{"Riposte", COUNTER, "Dexterity", "Dexterity", 14, -5, false, nil},
+		{"Vanish", HEAL, "Dexterity", "Intellect", 8, -20, false, nil},
+
+		// cleric, modest attacks and strong protection and healing
+		{"Smite", ATTACK, "Intellect", "Strength", 20, 25, false, nil},
+		{"Mace Strike", ATTACK, "Strength", "Strength", 16, 12, false, nil},
+		{"Holy Light", ATTACK, "Dexterity", "Intellect", 18, 15, false, nil},
+		{"Sanctuary", REPAIR, "Intellect", "Strength", 10, -10, false,
+			&EffectSpec{Kind: "shielded", Turns: 3, Power: 5}},
+		{"Rebuke", COUNTER, "Strength", "Intellect", 8, -5, false, nil},
+		{"Prayer", HEAL, "Intellect", "Intellect", 16, -15, false, nil},
+	}
+}
+
+// LoadMoves replaces the move registry with the moves in file fn. Classes
+// have to be loaded again afterwards to check the moves they name
+func LoadMoves(fn string) error {
+	body, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return err
+	}
+
+	var defs []MoveDef
+	err = json.Unmarshal(body, &defs)
+	if err != nil {
+		return err
+	}
+
+	err = validateMoves(defs)
+	if err != nil {
+		return fmt.Errorf("%s: %v", fn, err)
+	}
+	moves = defs
+	return nil
+}
+
+// validateMoves checks that defs can be used as the move registry
+func validateMoves(defs []MoveDef) error {
+	if len(defs) == 0 {
+		return errors.New("No moves defined")
+	}
+
+	seen := make(map[string]bool)
+	for i, def := range defs {
+		if def.Name == "" {
+			return fmt.Errorf("Move %d has no name", i)
+		}
+		if seen[def.Name] {
+			return fmt.Errorf("Move %s defined twice", def.Name)
+		}
+		seen[def.Name] = true
+
+		err := def.validate()
+		if err != nil {
+			return fmt.Errorf("Move %s %v", def.Name, err)
+		}
+	}
+	return nil
+}
+
+// validate checks that the move has a known outcome and attributes and
+// rolls sensible amounts
+func (def MoveDef) validate() error {
+	switch def.Outcome {
+	case ATTACK, REPAIR, COUNTER, HEAL:
+	default:
+		return fmt.Errorf("has unknown outcome %s", def.Outcome)
+	}
+	if !isAttribute(def.Success) || !isAttribute(def.Damage) {
+		return errors.New("has unknown attributes")
+	}
+	if def.Base < 1 || def.Base > 40 || def.Cost < -50 || def.Cost > 50 {
+		return errors.New("has base or cost out of bounds")
+	}
+	if def.Pierce && (def.Outcome == REPAIR || def.Outcome == HEAL) {
+		return errors.New("pierces without dealing damage")
+	}
+	if def.Effect != nil {
+		if err := def.Effect.validate(); err != nil {
+			return fmt.Errorf("has an invalid effect: %v", err)
+		}
+	}
+	return nil
+}
+
+// isAttribute returns whether name is an attribute moves can check
+func isAttribute(name string) bool {
+	return name == "Strength" || name == "Dexterity" || name == "Intellect"
+}
+
+// attribute returns the attribute of c called name as rolls use it, kept
+// between 0 and 1 whatever the equipment of c adds
+func attribute(c *Class, name string) float32 {
+	a := attributeOf(c, name)
+	switch {
+	case a == nil || *a < 0:
+		return 0
+	case *a > 1:
+		return 1
+	}
+	return *a
+}
+
+// attributeOf returns the attribute of c called name to be changed, nil if
+// there is no such attribute
+func attributeOf(c *Class, name string) *float32 {
+	switch name {
+	case "Strength":
+		return &c.Strength
+	case "Dexterity":
+		return &c.Dexterity
+	case "Intellect":
+		return &c.Intellect
+	}
+	return nil
+}
+
+// Moves returns the move registry
+func Moves() []MoveDef {
+	return moves
+}
+
+// LookupMove returns the definition of the move called name
+func LookupMove(name string) (MoveDef, bool) {
+	for _, def := range moves {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return MoveDef{}, false
+}
+
+// MoveOf returns the definition of the move c plays in slot m, the base move
+// of the slot if the class of c or its move is unknown
+func MoveOf(c *Class, m Move) MoveDef {
+	class, ok := LookupClass(c.ClassName)
+	if !ok {
+		return baseMoves[m]
+	}
+	def, ok := LookupMove(class.Moves[m])
+	if !ok {
+		return baseMoves[m]
+	}
+	return def
+}
+
+// roll rolls the outcome of c playing def against e with dice d. Attacks
+// return their damage, 0 on a miss, rolled by the combat model along with
+// how they landed. Defenses return the amount they repair, counter or heal,
+// 0 or a negative backfire on failure
+func (def MoveDef) roll(c, e *Class, d Dice) (int, hitKind) {
+	if def.Outcome == ATTACK {
+		return COMBAT.rollAttack(c, e, def, d)
+	}
+
+	n := int(attribute(c, def.Damage)*float32(def.Base) + .5)
+	if n < 1 {
+		// a low base scaled by a low attribute rounds down to nothing
+		return 0, NORMAL_HIT
+	}
+	if d.Float32() < attribute(c, def.Success) {
+		return d.Intn(n), NORMAL_HIT
+	}
+	if def.Outcome == COUNTER {
+		return -d.Intn(n), NORMAL_HIT
+	}
+	return 0, NORMAL_HIT
+}
+
+// Summary describes what def checks, what it does and what it costs
+func (def MoveDef) Summary() string {
+	short := func(attr string) string {
+		return strings.ToLower(attr[:3])
+	}
+
+	var parts []string
+	switch def.Outcome {
+	case ATTACK:
+		parts = append(parts, fmt.Sprintf("vs %s, %s damage",
+			short(def.Success), short(def.Damage)))
+	case REPAIR:
+		parts = append(parts, short(def.Success)+", repairs armor")
+	case COUNTER:
+		parts = append(parts, short(def.Success)+", counters")
+	case HEAL:
+		parts = append(parts, short(def.Success)+", heals")
+	}
+	if def.Pierce {
+		parts = append(parts, "pierces")
+	}
+	if def.Effect != nil {
+		parts = append(parts, def.Effect.Kind)
+	}
+	parts = append(parts, fmt.Sprintf("%+d stamina", -def.Cost))
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,135 @@
+package game
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestShippedMoves checks that the shipped move file holds the default moves
+// and that every default class plays its slots differently from the base
+// moves and from every other class, apart from names, pierces and effects
+func TestShippedMoves(t *testing.T) {
+	defer func() { moves = DefaultMoves() }()
+
+	err := LoadMoves("../" + MOVES_FILE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(moves, DefaultMoves()) {
+		t.Errorf("Got moves %v, expected the default moves", moves)
+	}
+
+	stats := func(c *Class) [6]MoveDef {
+		var s [6]MoveDef
+		for m := HEAVY; m <= EVADE; m++ {
+			def := MoveOf(c, m)
+			def.Name, def.Pierce, def.Effect = "", false, nil
+			s[m] = def
+		}
+		return s
+	}
+	seen := map[[6]MoveDef]string{stats(&Class{}): "base moves"}
+	for _, class := range DefaultClasses() {
+		s := stats(&Class{ClassName: class.Name})
+		if other, ok := seen[s]; ok {
+			t.Errorf("%s plays its moves like %s", class.Name, other)
+		}
+		seen[s] = class.Name
+	}
+}
+
+// TestLoadMovesInvalid checks that invalid move files are rejected
+func TestLoadMovesInvalid(t *testing.T) {
+	defer func() { moves = DefaultMoves() }()
+
+	cases := map[string]string{
+		"empty": `[]`,
+		"twice": `[{"Name": "A", "Outcome": "attack", "Success": "Strength",` +
+			` "Damage": "Strength", "Base": 20}, {"Name": "A", "Outcome":` +
+			` "heal", "Success": "Strength", "Damage": "Strength",` +
+			` "Base": 10}]`,
+		"outcome": `[{"Name": "A", "Outcome": "dance", "Success":` +
+			` "Strength", "Damage": "Strength", "Base": 20}]`,
+		"attribute": `[{"Name": "A", "Outcome": "attack", "Success": "Luck",` +
+			` "Damage": "Strength", "Base": 20}]`,
+		"base": `[{"Name": "A", "Outcome": "attack", "Success": "Strength",` +
+			` "Damage": "Strength"}]`,
+		"pierce": `[{"Name": "A", "Outcome": "heal", "Success": "Strength",` +
+			` "Damage": "Strength", "Base": 10, "Pierce": true}]`,
+	}
+	for name, body := range cases {
+		f, err := ioutil.TempFile("", "moves")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(f.Name())
+		f.WriteString(body)
+		f.Close()
+
+		if LoadMoves(f.Name()) == nil {
+			t.Errorf("Loaded invalid move file %s", name)
+		}
+	}
+	if len(moves) != len(DefaultMoves()) {
+		t.Errorf("Got %d moves after invalid loads, expected %d", len(moves),
+			len(DefaultMoves()))
+	}
+}
+
+// TestCustomMoves checks that turns are resolved by the definitions of the
+// moves a class has rather than by their slots
+func TestCustomMoves(t *testing.T) {
+	defer func() {
+		moves = DefaultMoves()
+		classes = DefaultClasses()
+	}()
+
+	moves = append(moves,
+		MoveDef{Name: "Drain", Outcome: ATTACK, Success: "Strength",
+			Damage: "Dexterity", Base: 10, Cost: 5},
+		MoveDef{Name: "Mend", Outcome: HEAL, Success: "Dexterity",
+			Damage: "Dexterity", Base: 10, Cost: -5})
+	def := classes[0]
+	def.Name = "Monk"
+	def.Moves = [6]string{"Drain", "Quick Thrust", "Sword Slash", "Shield",
+		"Mend", "Drink Potion"}
+	classes = append(classes, def)
+
+	base := Class{Health: 50, Strength: .5, Dexterity: 1, Intellect: 0}
+	monk, knight := base, base
+	monk.ClassName, knight.ClassName = "Monk", "Knight"
+
+	// the heavy slot of the monk rolls at most 10 damage with its dexterity
+	// and checks the strength of the knight rather than its intellect
+	resolveTurn(&monk, &knight, HEAVY, BLOCK, fixedDice{.9, 20})
+	if knight.Health != 39 {
+		t.Errorf("Got knight health %d, expected 39", knight.Health)
+	}
+
+	// the parry slot of the monk heals instead of countering
+	monk.Health = 50
+	resolveTurn(&knight, &monk, QUICK, PARRY, fixedDice{.1, 20})
+	if monk.Health != 59 || knight.Health != 39 {
+		t.Errorf("Got monk and knight health %d and %d, expected 59 and 39",
+			monk.Health, knight.Health)
+	}
+}
+
+// TestRollNothing checks that defenses whose scaled base rounds down to
+// nothing roll 0 rather than panicking
+func TestRollNothing(t *testing.T) {
+	c := Class{Strength: 1, Dexterity: .25, Intellect: 0}
+	heal := MoveDef{Name: "Mend", Outcome: HEAL, Success: "Strength",
+		Damage: "Dexterity", Base: 1}
+	counter := MoveDef{Name: "Jab", Outcome: COUNTER, Success: "Intellect",
+		Damage: "Dexterity", Base: 1}
+
+	if n, _ := heal.roll(&c, &Class{}, sharedDice{}); n != 0 {
+		t.Errorf("Got heal of %d, expected 0", n)
+	}
+	if n, _ := counter.roll(&c, &Class{}, sharedDice{}); n != 0 {
+		t.Errorf("Got backfire of %d, expected 0", n)
+	}
+}
@@ -0,0 +1,148 @@
+package game
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Layer is a fully connected layer, W holds Out rows of In weights
+type Layer struct {
+	In  int
+	Out int
+	W   []float32
+	B   []float32
+}
+
+// Network is a feed forward network with relu activations on every layer
+// except the last which is linear
+type Network struct {
+	Layers []Layer
+}
+
+// NewNetwork creates a network with the given layer sizes, sizes[0] being the
+// number of inputs. Weights use he initialization
+func NewNetwork(sizes ...int) *Network {
+	n := &Network{}
+	for i := 1; i < len(sizes); i++ {
+		l := Layer{
+			In:  sizes[i-1],
+			Out: sizes[i],
+			W:   make([]float32, sizes[i-1]*sizes[i]),
+			B:   make([]float32, sizes[i]),
+		}
+		scale := math.Sqrt(2 / float64(l.In))
+		for j := range l.W {
+			l.W[j] = float32(rand.NormFloat64() * scale)
+		}
+		n.Layers = append(n.Layers, l)
+	}
+	return n
+}
+
+// Copy returns a deep copy of the network
+func (n *Network) Copy() *Network {
+	c := &Network{Layers: make([]Layer, len(n.Layers))}
+	for i, l := range n.Layers {
+		c.Layers[i] = Layer{
+			In:  l.In,
+			Out: l.Out,
+			W:   append([]float32(nil), l.W...),
+			B:   append([]float32(nil), l.B...),
+		}
+	}
+	return c
+}
+
+// forward returns the activations of every layer for input x, the first
+// entry being x itself and the last the output of the network
+func (n *Network) forward(x []float32) [][]float32 {
+	acts := [][]float32{x}
+	for i, l := range n.Layers {
+		out := make([]float32, l.Out)
+		for o := 0; o < l.Out; o++ {
+			sum := l.B[o]
+			row := l.W[o*l.In : (o+1)*l.In]
+			for j, v := range x {
+				sum += row[j] * v
+			}
+			if i < len(n.Layers)-1 && sum < 0 {
+				sum = 0
+			}
+			out[o] = sum
+		}
+		acts = append(acts, out)
+		x = out
+	}
+	return acts
+}
+
+// Predict returns the output of the network for input x
+func (n *Network) Predict(x []float32) []float32 {
+	acts := n.forward(x)
+	return acts[len(acts)-1]
+}
+
+// gradients holds the accumulated gradient of every weight and bias
+type gradients struct {
+	W [][]float32
+	B [][]float32
+}
+
+// newGradients returns zeroed gradients shaped like network n
+func newGradients(n *Network) *gradients {
+	g := &gradients{}
+	for _, l := range n.Layers {
+		g.W = append(g.W, make([]float32, len(l.W)))
+		g.B = append(g.B, make([]float32, len(l.B)))
+	}
+	return g
+}
+
+// backward accumulates into g the gradients for input x when the error of
+// the output is outErr
+func (n *Network) backward(x []float32, outErr []float32, g *gradients) {
+	acts := n.forward(x)
+	delta := outErr
+	for i := len(n.Layers) - 1; i >= 0; i-- {
+		l := n.Layers[i]
+		in := acts[i]
+
+		var prev []float32
+		if i > 0 {
+			prev = make([]float32, l.In)
+		}
+		for o := 0; o < l.Out; o++ {
+			if delta[o] == 0 {
+				continue
+			}
+			g.B[i][o] += delta[o]
+			row := l.W[o*l.In : (o+1)*l.In]
+			grow := g.W[i][o*l.In : (o+1)*l.In]
+			for j, v := range in {
+				grow[j] += delta[o] * v
+				if prev != nil {
+					prev[j] += delta[o] * row[j]
+				}
+			}
+		}
+		// relu derivative of the previous layer
+		for j := range prev {
+			if in[j] <= 0 {
+				prev[j] = 0
+			}
+		}
+		delta = prev
+	}
+}
+
+// apply takes a gradient descent step of size rate using gradients g
+func (n *Network) apply(g *gradients, rate float32) {
+	for i, l := range n.Layers {
+		for j := range l.W {
+			l.W[j] -= rate * g.W[i][j]
+		}
+		for j := range l.B {
+			l.B[j] -= rate * g.B[i][j]
+		}
+	}
+}
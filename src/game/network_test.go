@@ -0,0 +1,43 @@
+package game
+
+import "testing"
+
+// TestNetworkLearns checks that gradient steps reduce the error of the
+// network on a fixed target
+func TestNetworkLearns(t *testing.T) {
+	n := NewNetwork(3, 8, 2)
+	x := []float32{.5, -.2, .8}
+	target := []float32{1, -1}
+
+	loss := func() float32 {
+		var l float32
+		for i, v := range n.Predict(x) {
+			l += (v - target[i]) * (v - target[i])
+		}
+		return l
+	}
+
+	before := loss()
+	for i := 0; i < 100; i++ {
+		g := newGradients(n)
+		out := n.Predict(x)
+		n.backward(x, []float32{out[0] - target[0], out[1] - target[1]}, g)
+		n.apply(g, .05)
+	}
+	after := loss()
+
+	if after >= before || after > .01 {
+		t.Errorf("Loss went from %f to %f, expected it to approach 0",
+			before, after)
+	}
+}
+
+// TestNetworkCopy checks that copies do not share weights
+func TestNetworkCopy(t *testing.T) {
+	n := NewNetwork(2, 2)
+	c := n.Copy()
+	c.Layers[0].W[0] += 1
+	if n.Layers[0].W[0] == c.Layers[0].W[0] {
+		t.Errorf("Copy shares weights with original network")
+	}
+}
@@ -0,0 +1,106 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+)
+
+// worker creates a reinforcement agent learning like a on its own copy of
+// the tables of a, with its own dice and exploration schedule
+func (a *Reinforcement) worker() *Reinforcement {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// double q-learning starts its second estimate as a copy of the first,
+	// which has to be shared before the workers take their bases so they
+	// only merge what they learned into it
+	if _, ok := a.Rule.(DoubleQ); ok && a.QT2 == nil {
+		a.QT2 = a.QT.Copy()
+	}
+
+	w := NewReinforcement(a.QT.Copy(), a.Rule)
+	if a.QT2 != nil {
+		w.QT2 = a.QT2.Copy()
+	}
+	w.LearningRate = a.LearningRate
+	w.Discount = a.Discount
+	w.Reward = a.Reward
+	w.Planning = a.Planning
+	w.Metrics = a.Metrics
+	w.Dice = newDice()
+	return w
+}
+
+// merge adds the change worker w made to its tables since they were copied
+// from base into the tables of a, then resets w and base to the merged tables
+func (a *Reinforcement) merge(w *Reinforcement, base, base2 QTable) (QTable,
+	QTable) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	mergeDelta(a.QT, w.QT, base)
+	for state, n := range w.Visits {
+		a.Visits[state] += n
+	}
+	w.Visits = make(Visits)
+	if w.QT2 != nil {
+		if a.QT2 == nil {
+			a.QT2 = make(QTable)
+		}
+		mergeDelta(a.QT2, w.QT2, base2)
+	}
+
+	w.QT, base = a.QT.Copy(), a.QT.Copy()
+	if a.QT2 != nil {
+		w.QT2, base2 = a.QT2.Copy(), a.QT2.Copy()
+	}
+	return base, base2
+}
+
+// mergeDelta adds the difference between local and base to shared
+func mergeDelta(shared, local, base QTable) {
+	for state, values := range local {
+		from := base.get(state)
+		row := shared.row(state)
+		for i, v := range values {
+			row[i] += v - from[i]
+		}
+	}
+}
+
+// ParallelSelfPlay trains the tables of a by playing episodes matches
+// against opponents created by opponent, spread across workers goroutines.
+// Every worker plays on its own copy of the tables with its own dice so
+// matches never wait on each other, and adds what it learned to a every
+// mergeEvery matches
+func ParallelSelfPlay(a *Reinforcement, opponent func() Agent, episodes,
+	workers, mergeEvery int) {
+	if mergeEvery < 1 {
+		mergeEvery = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		// spread the remainder over the first workers
+		n := episodes / workers
+		if i < episodes%workers {
+			n++
+		}
+
+		wg.Add(1)
+		go func(id, n int) {
+			defer wg.Done()
+
+			w := a.worker()
+			op := opponent()
+			base, base2 := w.QT.Copy(), w.QT2.Copy()
+			for j := 0; j < n; j++ {
+				playMatch(w, op, fmt.Sprintf("worker%d-%d", id, j), w.Dice)
+				if (j+1)%mergeEvery == 0 || j == n-1 {
+					base, base2 = a.merge(w, base, base2)
+				}
+			}
+		}(i, n)
+	}
+	wg.Wait()
+}
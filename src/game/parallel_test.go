@@ -0,0 +1,104 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestMergeDelta checks that concurrent changes to the same value add up
+func TestMergeDelta(t *testing.T) {
+	shared := QTable{1: {1, 1, 1, 1, 1, 1}}
+	base := shared.Copy()
+
+	w1, w2 := base.Copy(), base.Copy()
+	w1[1][HEAVY] += .5
+	w2[1][HEAVY] += .25
+	w2.row(2)[BLOCK] = 1
+
+	mergeDelta(shared, w1, base)
+	mergeDelta(shared, w2, base)
+
+	if v := shared[1][HEAVY]; v != 1.75 {
+		t.Errorf("Got merged value %f, expected 1.75", v)
+	}
+	if v := shared.get(2)[BLOCK]; v != 1 {
+		t.Errorf("Got new state value %f, expected 1", v)
+	}
+}
+
+// TestParallelSelfPlay checks that every worker merges into the shared table
+func TestParallelSelfPlay(t *testing.T) {
+	a := NewReinforcement(make(QTable), QLearning{})
+	a.Metrics = nil
+	ParallelSelfPlay(a, func() Agent { return randAgent{} }, 40, 4, 3)
+
+	if len(a.QT) == 0 {
+		t.Errorf("Shared table is empty after training")
+	}
+}
+
+// TestParallelDoubleQ checks that workers starting without a second table
+// merge what they learned into it rather than whole copies of the first
+func TestParallelDoubleQ(t *testing.T) {
+	a := NewReinforcement(QTable{1: {5, 5, 5, 5, 5, 5}}, DoubleQ{})
+	a.LearningRate, a.Discount = .5, .3
+	a.Metrics = nil
+
+	// every worker takes its bases before any of them merges
+	var workers []*Reinforcement
+	var bases, bases2 []QTable
+	for i := 0; i < 4; i++ {
+		w := a.worker()
+		workers = append(workers, w)
+		bases = append(bases, w.QT.Copy())
+		bases2 = append(bases2, w.QT2.Copy())
+	}
+	for i, w := range workers {
+		for j := 0; j < 5; j++ {
+			playMatch(w, randAgent{}, fmt.Sprintf("worker%d-%d", i, j),
+				w.Dice)
+		}
+		a.merge(w, bases[i], bases2[i])
+	}
+
+	if len(a.QT2) != len(a.QT) {
+		t.Errorf("Got %d states in the second table, expected %d",
+			len(a.QT2), len(a.QT))
+	}
+	// the values the second table started from are counted once
+	if v := a.QT2.get(1)[HEAVY]; v != 5 {
+		t.Errorf("Got value %f in the second table, expected 5", v)
+	}
+	for state, values := range a.QT2 {
+		for _, v := range values {
+			if v > 10 || v < -10 {
+				t.Fatalf("Got value %f in state %d of the second table",
+					v, state)
+			}
+		}
+	}
+
+	// the same holds when the workers run concurrently
+	a = NewReinforcement(make(QTable), DoubleQ{})
+	a.Metrics = nil
+	ParallelSelfPlay(a, func() Agent { return randAgent{} }, 40, 4, 3)
+	if len(a.QT2) != len(a.QT) {
+		t.Errorf("Got %d states in the second table after parallel self"+
+			" play, expected %d", len(a.QT2), len(a.QT))
+	}
+}
+
+// BenchmarkParallelSelfPlay measures matches trained per second as the
+// number of workers grows, run with -cpu to vary the cores available
+func BenchmarkParallelSelfPlay(b *testing.B) {
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers%d", workers), func(b *testing.B) {
+			a := NewReinforcement(make(QTable), QLearning{})
+			a.Metrics = nil
+			b.ResetTimer()
+			ParallelSelfPlay(a, func() Agent { return randAgent{} }, b.N,
+				workers, 100)
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "matches/s")
+		})
+	}
+}
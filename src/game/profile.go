@@ -0,0 +1,91 @@
+package game
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+)
+
+// PROFILE_DIR is the directory minmax profiles are saved in
+const PROFILE_DIR = "./profiles/"
+
+// PROFILE is set by main using cmd line flags and selects the minmax profile
+// used by the server, nil uses the default profile
+var PROFILE *MinMaxProfile
+
+// MinMaxProfile holds the weights the minmax strategy combines its tables
+// with. The default profile weighs everything equally
+type MinMaxProfile struct {
+	Name      string
+	Tables    [3]float32 // weights of the damage, health and armor tables
+	Moves     [6]float32 // weight of each move
+	Sharpness float32    // exponent applied to move weights, >1 is greedier
+}
+
+var defaultProfile = DefaultProfile()
+
+// DefaultProfile returns the profile reproducing the unweighted strategy
+func DefaultProfile() MinMaxProfile {
+	return MinMaxProfile{
+		Name:      "default",
+		Tables:    [3]float32{1, 1, 1},
+		Moves:     [6]float32{1, 1, 1, 1, 1, 1},
+		Sharpness: 1,
+	}
+}
+
+// weighMoves applies the move weights and sharpness of the profile to the
+// move weights vals and renormalizes them to sum to 1
+func (prof *MinMaxProfile) weighMoves(vals []float32) []float32 {
+	var sum float32
+	for i, v := range vals {
+		if prof.Sharpness != 1 {
+			v = float32(math.Pow(float64(v), float64(prof.Sharpness)))
+		}
+		vals[i] = v * prof.Moves[i]
+		sum += vals[i]
+	}
+
+	for i := range vals {
+		if sum > 0 {
+			vals[i] /= sum
+		} else {
+			vals[i] = 1 / float32(len(vals))
+		}
+	}
+	return vals
+}
+
+// NewMinMaxAgent returns a minmax agent weighted by profile prof
+func NewMinMaxAgent(prof MinMaxProfile) Agent {
+	return minMaxAgent{&prof}
+}
+
+// LoadMinMaxProfile reads the profile called name from PROFILE_DIR
+func LoadMinMaxProfile(name string) (MinMaxProfile, error) {
+	var prof MinMaxProfile
+
+	body, err := ioutil.ReadFile(PROFILE_DIR + name + ".json")
+	if err != nil {
+		return prof, err
+	}
+
+	err = json.Unmarshal(body, &prof)
+	return prof, err
+}
+
+// SaveMinMaxProfile writes profile prof to PROFILE_DIR using its name
+func SaveMinMaxProfile(prof MinMaxProfile) error {
+	err := os.MkdirAll(PROFILE_DIR, 0777)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.MarshalIndent(prof, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(PROFILE_DIR+prof.Name+".json", body, 0666)
+}
@@ -0,0 +1,31 @@
+package game
+
+import "testing"
+
+// TestProfileWeights checks that weighted minmax weights still sum to 1 and
+// that a move with no weight is never chosen
+func TestProfileWeights(t *testing.T) {
+	p := mustClass("Archer", "Archer")
+	e := mustClass("Knight", "Knight")
+
+	prof := DefaultProfile()
+	prof.Moves[PARRY] = 0
+	prof.Sharpness = 2
+
+	for _, pr := range []*MinMaxProfile{nil, &prof} {
+		var sum float32
+		for _, v := range normalizedMinMaxes(&p, &e, pr) {
+			sum += v
+		}
+		if sum < .999 || sum > 1.001 {
+			t.Errorf("Weights sum to %f, expected 1", sum)
+		}
+	}
+
+	for i := 0; i < nClassTests; i++ {
+		if getTurnMinMax(&p, &e, &prof) == PARRY {
+			t.Errorf("Chose move with no weight on test %d", i)
+			break
+		}
+	}
+}
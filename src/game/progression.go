@@ -0,0 +1,176 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+// xpPerWin is the experience a win is worth per level of the opponent
+const xpPerWin = 100
+
+// pointsPerLevel is the number of attribute points every level up grants
+const pointsPerLevel = 2
+
+// attributeStep is how much an allocated point raises an attribute
+const attributeStep = .05
+
+// level returns the level c plays at, characters created before levels play
+// at level 1
+func (c *Class) level() int {
+	if c.Level < 1 {
+		return 1
+	}
+	return c.Level
+}
+
+// HealthCap returns the most health c can heal to, 100 at level 1 and 10
+// more every level after, raised by the armor c has equipped
+func (c *Class) HealthCap() int {
+	return 100 + 10*(c.level()-1) + c.gear().Health
+}
+
+// ArmorCap returns the most armor c can repair to, 20 at level 1 and 2 more
+// every level after, raised by the armor c has equipped
+func (c *Class) ArmorCap() int {
+	return 20 + 2*(c.level()-1) + c.gear().Armor
+}
+
+// XPToLevel returns the experience c needs for its next level
+func (c *Class) XPToLevel() int {
+	return 100 * c.level()
+}
+
+// LevelText describes the level of c, its progress to the next level and its
+// unspent points
+func (c *Class) LevelText() string {
+	s := fmt.Sprintf("%d (%d/%d XP)", c.level(), c.XP, c.XPToLevel())
+	if c.Points > 0 {
+		s += fmt.Sprintf(", %d points", c.Points)
+	}
+	return s
+}
+
+// AwardMatch records the result of a finished match of c against e, awarding
+// experience and an item for wins and levelling c up, and returns a
+// description of it
+func AwardMatch(c, e *Class, won bool) string {
+	if !won {
+		c.Losses++
+		return fmt.Sprintf("%s loses and gains no experience\n",
+			c.PlayerName)
+	}
+
+	c.Wins++
+	xp := xpPerWin * e.level()
+	c.XP += xp
+	res := fmt.Sprintf("%s wins and gains %d experience\n", c.PlayerName,
+		xp)
+	for c.XP >= c.XPToLevel() {
+		c.XP -= c.XPToLevel()
+		levelUp(c)
+		res += fmt.Sprintf("%s reaches level %d and has %d points to"+
+			" spend\n", c.PlayerName, c.Level, c.Points)
+	}
+	return res + dropItem(c, sharedDice{})
+}
+
+// levelUp raises c a level, granting attribute points and raising its max
+// health, armor and stamina
+func levelUp(c *Class) {
+	fillMax(c)
+	c.Level = c.level() + 1
+	c.Points += pointsPerLevel
+
+	c.MaxHealth += 10
+	if c.MaxHealth > c.HealthCap() {
+		c.MaxHealth = c.HealthCap()
+	}
+	c.MaxArmor += 2
+	if c.MaxArmor > c.ArmorCap() {
+		c.MaxArmor = c.ArmorCap()
+	}
+	if c.MaxStamina > 0 {
+		c.MaxStamina += 5
+	}
+}
+
+// fillMax sets the max health and armor of characters created before they
+// were stored to the most their class could roll
+func fillMax(c *Class) {
+	def, _ := LookupClass(c.ClassName)
+	if c.MaxHealth == 0 {
+		c.MaxHealth = int(def.Health.Max + .5)
+		if c.MaxHealth == 0 {
+			c.MaxHealth = 100
+		}
+	}
+	if c.MaxArmor == 0 {
+		c.MaxArmor = int(def.Armor.Max + .5)
+	}
+}
+
+// Allocate spends a point of c on the attribute called attr
+func Allocate(c *Class, attr string) error {
+	if c.Points < 1 {
+		return errors.New("No points to allocate")
+	}
+	if !isAttribute(attr) {
+		return errors.New("Could not parse attribute " + attr)
+	}
+
+	// equipment bonuses don't count towards the cap, taking them off may
+	// leave rounding errors smaller than half a step
+	a := attributeOf(c, attr)
+	bonus := c.gear().attribute(attr)
+	if *a-bonus > 1-attributeStep/2 {
+		return errors.New(attr + " is already at its cap")
+	}
+	*a += attributeStep
+	if *a-bonus > 1 {
+		*a = 1 + bonus
+	}
+	c.Points--
+	return nil
+}
+
+// Restore readies c for a new match, restoring its health, armor and stamina
+// and clearing its effects
+func Restore(c *Class) {
+	fillMax(c)
+	c.Health = c.MaxHealth
+	c.Armor = c.MaxArmor
+	c.Stamina = c.MaxStamina
+	c.Effects = [nEffects]Effect{}
+}
+
+// Promote levels c up to level, spending its points on random attributes and
+// equipping a random weapon and armor piece past level 1, for opponents
+// matched against characters of that level
+func Promote(c *Class, level int) {
+	d := sharedDice{}
+	for c.level() < level {
+		levelUp(c)
+	}
+	attrs := []string{"Strength", "Dexterity", "Intellect"}
+	for tries := 0; c.Points > 0 && tries < 100; tries++ {
+		Allocate(c, attrs[d.Intn(len(attrs))])
+	}
+	if level > 1 {
+		for _, kind := range []string{WEAPON, GEAR} {
+			var pool []string
+			for _, def := range items {
+				if def.Kind == kind {
+					pool = append(pool, def.Name)
+				}
+			}
+			if len(pool) == 0 {
+				continue
+			}
+			name := pool[d.Intn(len(pool))]
+			if addItem(c, name) {
+				Equip(c, name)
+			}
+		}
+	}
+	Restore(c)
+}
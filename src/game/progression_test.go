@@ -0,0 +1,69 @@
+package game
+
+import "testing"
+
+// TestAwardMatch checks that wins award experience by opponent level and
+// level characters up, raising their max stats, while losses only count
+func TestAwardMatch(t *testing.T) {
+	c := mustClass("Knight", "c")
+	c.MaxHealth, c.MaxArmor, c.MaxStamina = 95, 20, 60
+	e := mustClass("Archer", "e")
+
+	AwardMatch(&c, &e, false)
+	if c.Losses != 1 || c.XP != 0 {
+		t.Errorf("Got %d losses %d XP, expected 1 and 0", c.Losses, c.XP)
+	}
+
+	e.Level = 3
+	AwardMatch(&c, &e, true)
+	// 300 XP takes c past level 2 at 100 and level 3 at 200
+	if c.Wins != 1 || c.Level != 3 || c.XP != 0 || c.Points != 4 {
+		t.Errorf("Got %d wins level %d %d XP %d points, expected 1, 3, 0"+
+			" and 4", c.Wins, c.Level, c.XP, c.Points)
+	}
+	if c.MaxHealth != 115 || c.MaxArmor != 24 || c.MaxStamina != 70 {
+		t.Errorf("Got max health %d armor %d stamina %d, expected 115, 24"+
+			" and 70", c.MaxHealth, c.MaxArmor, c.MaxStamina)
+	}
+}
+
+// TestAllocate checks that points raise attributes up to their cap
+func TestAllocate(t *testing.T) {
+	c := Class{Strength: .95, Points: 2}
+
+	if err := Allocate(&c, "Luck"); err == nil {
+		t.Errorf("Allocated a point to an unknown attribute")
+	}
+	if err := Allocate(&c, "Strength"); err != nil || c.Strength != 1 {
+		t.Errorf("Got strength %.2f with error %v, expected 1", c.Strength,
+			err)
+	}
+	if err := Allocate(&c, "Strength"); err == nil {
+		t.Errorf("Allocated a point to a capped attribute")
+	}
+	Allocate(&c, "Dexterity")
+	if err := Allocate(&c, "Dexterity"); err == nil || c.Points != 0 {
+		t.Errorf("Allocated %d more points than c had", -c.Points)
+	}
+}
+
+// TestLevelCaps checks that higher levels heal and repair above the caps of
+// level 1 and that restoring fills characters saved before max stats
+func TestLevelCaps(t *testing.T) {
+	old := Class{ClassName: "Wizard", Health: 0, Armor: 3}
+	Restore(&old)
+	if old.Health != 100 || old.Armor != 20 {
+		t.Errorf("Got %d health %d armor, expected 100 and 20", old.Health,
+			old.Armor)
+	}
+
+	c := Class{Health: 100, Armor: 20, Strength: 1, Intellect: 1, Level: 3}
+	e := Class{Health: 100, Strength: 1}
+	miss := fixedDice{.1, 20}
+	resolveTurn(&e, &c, QUICK, BLOCK, miss)
+	resolveTurn(&e, &c, QUICK, EVADE, miss)
+	if c.Armor != 24 || c.Health != 109 {
+		t.Errorf("Got %d armor %d health, expected 24 and 109", c.Armor,
+			c.Health)
+	}
+}
@@ -0,0 +1,354 @@
+package game
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+)
+
+// QTable maps an encoded state to the expected reward of each move
+type QTable map[uint32][]float32
+
+// Learner is implemented by agents which learn from the turns they play
+type Learner interface {
+	Agent
+	// Observe is called after every turn with the characters before and
+	// after the turn, from the perspective of the learner where e is the
+	// character it controls and m the move it played. key identifies the
+	// match so learners can carry state between turns
+	Observe(key string, p, e *Class, m Move, nextP, nextE *Class, done bool)
+	// Save persists what the learner has learned so far
+	Save() error
+}
+
+// Reinforcement is the qtable agent, it selects moves from its qtable and
+// updates it with its update rule as it observes turns
+type Reinforcement struct {
+	QT           QTable
+	QT2          QTable // second estimate, only used by double q-learning
+	Rule         UpdateRule
+	LearningRate float32
+	Discount     float32
+	Explore      ExplorePolicy
+	Reward       RewardPreset
+	Planning     int        // simulated updates made from Model per real turn
+	Model        *Model     // transition model, nil until planning starts
+	Visits       Visits     // times each state was learned from
+	File         string     // file the qtable is saved to, empty to never save
+	Metrics      *Telemetry // training telemetry, nil to record none
+	Dice         Dice       // source of randomness, nil for the shared one
+
+	episodes map[string]*Episode
+	mu       sync.Mutex
+}
+
+// QT_DIR is the directory per player qtables are saved in
+const QT_DIR = "./qtables/"
+
+// PerPlayer is set by main using cmd line flags, when set every player gets
+// their own qtable which starts as a copy of the shared one
+var PerPlayer bool
+
+var server *Reinforcement
+var players = make(map[string]*Reinforcement)
+var serverLock sync.Mutex
+
+// get returns the values of state, or zeros if the state has not been seen
+func (qt QTable) get(state uint32) []float32 {
+	r, ok := qt[state]
+	if !ok {
+		return make([]float32, 6)
+	}
+	return r
+}
+
+// row returns the values of state, adding a zeroed row if the state has not
+// been seen yet
+func (qt QTable) row(state uint32) []float32 {
+	r, ok := qt[state]
+	if !ok {
+		r = make([]float32, 6)
+		qt[state] = r
+	}
+	return r
+}
+
+// Copy returns a deep copy of the qtable
+func (qt QTable) Copy() QTable {
+	c := make(QTable, len(qt))
+	for s, r := range qt {
+		c[s] = append([]float32(nil), r...)
+	}
+	return c
+}
+
+// Save writes the qtable to file fn
+func (qt QTable) Save(fn string) error {
+	f, err := os.Create(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := gob.NewEncoder(f)
+	return enc.Encode(qt)
+}
+
+// LoadQTable reads a qtable from file fn
+func LoadQTable(fn string) (QTable, error) {
+	qt := make(QTable)
+
+	f, err := os.Open(fn)
+	if err != nil {
+		return qt, err
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(f)
+	err = dec.Decode(&qt)
+	if err != nil {
+		return qt, err
+	}
+
+	return qt, nil
+}
+
+// NewReinforcement creates a reinforcement agent using qtable qt and update
+// rule rule, hyperparameters and exploration are taken from the values set
+// by main
+func NewReinforcement(qt QTable, rule UpdateRule) *Reinforcement {
+	return &Reinforcement{
+		QT:           qt,
+		Rule:         rule,
+		LearningRate: LearningRate,
+		Discount:     Discount,
+		Explore:      NewExplorePolicy(),
+		Reward:       REWARD,
+		Planning:     PlanningSteps,
+		Metrics:      METRICS,
+		Visits:       make(Visits),
+		episodes:     make(map[string]*Episode),
+	}
+}
+
+// LoadReinforcement creates a reinforcement agent from the qtable saved in
+// fn, starting from an empty table if fn does not exist. The agent saves back
+// to fn
+func LoadReinforcement(fn string, rule UpdateRule) (*Reinforcement, error) {
+	qt, err := LoadQTable(fn)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	a := NewReinforcement(qt, rule)
+	a.File = fn
+
+	qt2, err := LoadQTable(fn + "-qt2")
+	if err == nil {
+		a.QT2 = qt2
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	model, err := LoadModel(fn + "-model")
+	if err == nil {
+		a.Model = model
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	visits, err := LoadVisits(fn + "-visits")
+	if err == nil {
+		a.Visits = visits
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// serverReinforcement returns the reinforcement agent used by the server,
+// loading its qtable on first use
+func serverReinforcement() *Reinforcement {
+	serverLock.Lock()
+	defer serverLock.Unlock()
+
+	if server == nil {
+		a, err := LoadReinforcement("qtable", UPDATE_RULE)
+		if err != nil {
+			panic(err)
+		}
+		server = a
+		fmt.Println("QT initialized")
+	}
+	return server
+}
+
+// playerReinforcement returns the reinforcement agent which only plays
+// against player, starting from a copy of the shared qtable for new players
+func playerReinforcement(player string) *Reinforcement {
+	serverLock.Lock()
+	defer serverLock.Unlock()
+
+	a, ok := players[player]
+	if ok {
+		return a
+	}
+
+	err := os.MkdirAll(QT_DIR, 0777)
+	if err != nil {
+		panic(err)
+	}
+
+	fn := QT_DIR + player
+	_, err = os.Stat(fn)
+	isNew := os.IsNotExist(err)
+
+	a, err = LoadReinforcement(fn, UPDATE_RULE)
+	if err != nil {
+		panic(err)
+	}
+	if isNew {
+		qt, err := LoadQTable("qtable")
+		if err != nil && !os.IsNotExist(err) {
+			panic(err)
+		}
+		a.QT = qt
+	}
+	players[player] = a
+	fmt.Printf("QT initialized for %s\n", player)
+
+	return a
+}
+
+// values returns the values of every move in state. Only call with a.mu held
+func (a *Reinforcement) values(state uint32) []float32 {
+	vals := append([]float32(nil), a.QT.get(state)...)
+	if a.QT2 != nil {
+		for i, v := range a.QT2.get(state) {
+			vals[i] += v
+		}
+	}
+	return vals
+}
+
+// dice returns the source of randomness of the agent
+func (a *Reinforcement) dice() Dice {
+	if a.Dice == nil {
+		return sharedDice{}
+	}
+	return a.Dice
+}
+
+// GetTurn selects a move from the qtable values using the exploration policy
+func (a *Reinforcement) GetTurn(p, e *Class) Move {
+	state := getState(p, e)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.Explore.Select(a.dice(), state, a.values(state))
+}
+
+// Scores returns the qtable values of every move
+func (a *Reinforcement) Scores(p, e *Class) []float32 {
+	state := getState(p, e)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.values(state)
+}
+
+// Observe updates the qtable with the update rule. Transitions are held back
+// until the next move of the match is known so on-policy rules can use it
+func (a *Reinforcement) Observe(
+	key string, p, e *Class, m Move, nextP, nextE *Class, done bool,
+) {
+	state := getState(p, e)
+	nextState := getState(nextP, nextE)
+	t := Transition{
+		State:     state,
+		Action:    m,
+		Reward:    a.Reward.reward(state, nextState),
+		NextState: nextState,
+		Done:      done,
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.Metrics.reward(key, t.Reward)
+	a.Visits[state]++
+	ep, ok := a.episodes[key]
+	if !ok {
+		ep = &Episode{}
+		a.episodes[key] = ep
+	}
+	if ep.pending != nil {
+		ep.pending.NextAction = m
+		a.update(ep, *ep.pending)
+	}
+	if done {
+		a.update(ep, t)
+		delete(a.episodes, key)
+		a.Explore.EndEpisode()
+		a.Metrics.endEpisode(key, a.Explore, len(a.QT))
+	} else {
+		ep.pending = &t
+	}
+}
+
+// update applies the update rule to t and plans from the model. Only call with
+// a.mu held
+func (a *Reinforcement) update(ep *Episode, t Transition) {
+	a.Rule.Update(a, ep, t)
+	a.plan(t)
+}
+
+// Save writes the qtable to the file of the agent
+func (a *Reinforcement) Save() error {
+	if a.File == "" {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	err := a.QT.Save(a.File)
+	if err != nil {
+		return err
+	}
+	if a.QT2 != nil {
+		err = a.QT2.Save(a.File + "-qt2")
+		if err != nil {
+			return err
+		}
+	}
+	if a.Model != nil {
+		err = a.Model.Save(a.File + "-model")
+		if err != nil {
+			return err
+		}
+	}
+	return a.Visits.Save(a.File + "-visits")
+}
+
+// bestMove returns the move with the highest score
+func bestMove(scores []float32) Move {
+	var max float32 = -math.MaxFloat32
+	var m Move
+	for i, v := range scores {
+		if max < v {
+			max = v
+			m = Move(i)
+		}
+	}
+	return m
+}
+
+// matchKey identifies the match between p and e
+func matchKey(p, e *Class) string {
+	return p.PlayerName + p.ClassName + e.PlayerName + e.ClassName
+}
@@ -0,0 +1,73 @@
+package game
+
+import "errors"
+
+// RewardPreset weighs the changes between two states when rewarding the AI.
+// Gains are rewarded once per stat bucket changed and losses penalized
+type RewardPreset struct {
+	Name   string
+	Damage float32 // player health decreased
+	Break  float32 // player armor decreased
+	Heal   float32 // AI health increased
+	Repair float32 // AI armor increased
+	Hurt   float32 // AI health decreased, subtracted
+	Broken float32 // AI armor decreased, subtracted
+}
+
+// RewardPresets are the reward presets selectable by name
+var RewardPresets = map[string]RewardPreset{
+	"default":    {"default", 1.5, 1.5, 1, 1, .5, .5},
+	"aggressive": {"aggressive", 2, 1.5, .5, .5, .25, .25},
+	"defensive":  {"defensive", 1, 1, 1.5, 1.5, 1, 1},
+	"balanced":   {"balanced", 1, 1, 1, 1, 1, 1},
+}
+
+// REWARD is set by main using cmd line flags and is the reward preset of new
+// reinforcement agents
+var REWARD = RewardPresets["default"]
+
+// ParseRewardPreset returns the reward preset named s
+func ParseRewardPreset(s string) (RewardPreset, error) {
+	r, ok := RewardPresets[s]
+	if !ok {
+		return RewardPresets["default"],
+			errors.New("Could not parse reward preset")
+	}
+	return r, nil
+}
+
+// reward compares two states and rewards the AI for changes to its benefit
+// and penalizes it for changes to the benefit of the player
+func (r RewardPreset) reward(state, nextState uint32) float32 {
+	var reward float32
+	// extract p health, if decrease + reward
+	ph := (state & phMask) >> 8
+	phNext := (nextState & phMask) >> 8
+	if phNext < ph {
+		reward += r.Damage
+	}
+	// extract p armor, if decrease + reward
+	pa := (state & paMask) >> 6
+	paNext := (nextState & paMask) >> 6
+	if paNext < pa {
+		reward += r.Break
+	}
+	// extract e health, if increase + reward, if dec small penalty
+	eh := (state & ehMask) >> 2
+	ehNext := (nextState & ehMask) >> 2
+	if ehNext > eh {
+		reward += r.Heal
+	} else if ehNext < eh {
+		reward -= r.Hurt
+	}
+	// extract e armor, if increase + reward, if dec small penalty
+	ea := (state & eaMask)
+	eaNext := (nextState & eaMask)
+	if eaNext > ea {
+		reward += r.Repair
+	} else if eaNext < ea {
+		reward -= r.Broken
+	}
+
+	return reward
+}
@@ -0,0 +1,140 @@
+package game
+
+import "fmt"
+
+// ROUNDS is set by main using cmd line flags and is the number of rounds new
+// matches are played over, the first character to win most of them wins
+var ROUNDS = 1
+
+// Round is the result of a finished round of a match
+type Round struct {
+	Winner  string // player name of the winner, empty if both died
+	Turns   int
+	Health1 int // health both characters finished the round with
+	Health2 int
+}
+
+// Match tracks the rounds of a best of n match between two characters
+type Match struct {
+	BestOf int
+	Rounds []Round
+	Turns  int // turns played in the current round
+}
+
+// NewMatch returns a match over ROUNDS rounds
+func NewMatch() Match {
+	return Match{BestOf: ROUNDS}
+}
+
+// bestOf returns the number of rounds m is played over, matches stored before
+// rounds are played over one
+func (m *Match) bestOf() int {
+	if m.BestOf < 1 {
+		return 1
+	}
+	return m.BestOf
+}
+
+// Round returns the number of the round being played, counting from 1
+func (m *Match) Round() int {
+	return len(m.Rounds) + 1
+}
+
+// Wins returns the number of rounds the character called name won
+func (m *Match) Wins(name string) int {
+	var n int
+	for _, r := range m.Rounds {
+		if r.Winner == name {
+			n++
+		}
+	}
+	return n
+}
+
+// Over returns whether a character won most of the rounds of m or all of
+// them were played
+func (m *Match) Over() bool {
+	if len(m.Rounds) >= m.bestOf() {
+		return true
+	}
+	need := m.bestOf()/2 + 1
+	for _, r := range m.Rounds {
+		if r.Winner != "" && m.Wins(r.Winner) >= need {
+			return true
+		}
+	}
+	return false
+}
+
+// Winner returns the player name of the character of c1 and c2 who won more
+// rounds, empty if they won as many
+func (m *Match) Winner(c1, c2 *Class) string {
+	w1, w2 := m.Wins(c1.PlayerName), m.Wins(c2.PlayerName)
+	switch {
+	case w1 > w2:
+		return c1.PlayerName
+	case w2 > w1:
+		return c2.PlayerName
+	}
+	return ""
+}
+
+// EndRound records the round c1 and c2 just finished and, unless that ends the
+// match, restores both characters for the next round. It returns a
+// description of the round
+func (m *Match) EndRound(c1, c2 *Class) string {
+	r := Round{Turns: m.Turns, Health1: c1.Health, Health2: c2.Health}
+	switch {
+	case c1.Health > 0:
+		r.Winner = c1.PlayerName
+	case c2.Health > 0:
+		r.Winner = c2.PlayerName
+	}
+	m.Rounds = append(m.Rounds, r)
+	m.Turns = 0
+
+	if m.bestOf() == 1 {
+		return ""
+	}
+	res := fmt.Sprintf("Round %d is a draw", len(m.Rounds))
+	if r.Winner != "" {
+		res = fmt.Sprintf("%s wins round %d", r.Winner, len(m.Rounds))
+	}
+	res += fmt.Sprintf(", %s %d-%d %s\n", c1.PlayerName,
+		m.Wins(c1.PlayerName), m.Wins(c2.PlayerName), c2.PlayerName)
+
+	if !m.Over() {
+		Restore(c1)
+		Restore(c2)
+	}
+	return res
+}
+
+// Summary describes the result of every round of the finished match of c1
+// against c2 and of the match
+func (m *Match) Summary(c1, c2 *Class) string {
+	var res string
+	if w := m.Winner(c1, c2); w != "" {
+		res += fmt.Sprintf("%s wins the match %d-%d\n", w,
+			m.Wins(w), len(m.Rounds)-m.Wins(w)-m.draws())
+	} else {
+		res += fmt.Sprintf("The match is a draw %d-%d\n",
+			m.Wins(c1.PlayerName), m.Wins(c2.PlayerName))
+	}
+
+	for i, r := range m.Rounds {
+		winner := "draw"
+		if r.Winner != "" {
+			winner = r.Winner + " wins"
+		}
+		res += fmt.Sprintf("Round %d: %s in %d turns, %s %d health, %s %d"+
+			" health\n", i+1, winner, r.Turns, c1.PlayerName, r.Health1,
+			c2.PlayerName, r.Health2)
+	}
+	return res
+}
+
+// draws returns the number of rounds of m in which both characters died
+func (m *Match) draws() int {
+	return m.Wins("")
+}
@@ -0,0 +1,86 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestBestOfThree checks that rounds restore both characters until one of
+// them won two rounds, with drawn rounds counting towards the three
+func TestBestOfThree(t *testing.T) {
+	c1 := Class{PlayerName: "a", MaxHealth: 100, MaxArmor: 20}
+	c2 := Class{PlayerName: "b", MaxHealth: 90, MaxArmor: 10}
+	m := Match{BestOf: 3}
+
+	// a wins the first round
+	c1.Health, c2.Health, m.Turns = 40, 0, 12
+	m.EndRound(&c1, &c2)
+	if m.Over() || c1.Health != 100 || c2.Health != 90 || c2.Armor != 10 ||
+		m.Turns != 0 {
+		t.Errorf("Got over %t, health %d and %d, armor %d, turns %d after"+
+			" round 1, expected a restored match going on", m.Over(),
+			c1.Health, c2.Health, c2.Armor, m.Turns)
+	}
+
+	// both die in the second round
+	c1.Health, c2.Health = 0, 0
+	m.EndRound(&c1, &c2)
+	if m.Over() || m.Round() != 3 || m.Winner(&c1, &c2) != "a" {
+		t.Errorf("Got over %t round %d leader %s after a draw, expected"+
+			" round 3 led by a", m.Over(), m.Round(), m.Winner(&c1, &c2))
+	}
+
+	// b wins the third round, leaving the match drawn
+	c1.Health, c2.Health = 0, 30
+	res := m.EndRound(&c1, &c2)
+	if !m.Over() || m.Winner(&c1, &c2) != "" || c2.Health != 30 {
+		t.Errorf("Got over %t winner %s health %d after round 3, expected"+
+			" an unrestored draw", m.Over(), m.Winner(&c1, &c2), c2.Health)
+	}
+	if res != "b wins round 3, a 1-1 b\n" {
+		t.Errorf("Got round text %q", res)
+	}
+
+	summary := m.Summary(&c1, &c2)
+	if !strings.HasPrefix(summary, "The match is a draw 1-1\n") ||
+		!strings.Contains(summary, "Round 1: a wins in 12 turns, a 40"+
+			" health, b 0 health\n") {
+		t.Errorf("Got summary %q", summary)
+	}
+}
+
+// TestMajority checks that matches end once a character won most rounds
+func TestMajority(t *testing.T) {
+	c1 := Class{PlayerName: "a", MaxHealth: 100}
+	c2 := Class{PlayerName: "b", MaxHealth: 100}
+	m := Match{BestOf: 5}
+
+	for i := 0; i < 3; i++ {
+		if m.Over() {
+			t.Fatalf("Match over after %d rounds", i)
+		}
+		c1.Health, c2.Health = 0, 10
+		m.EndRound(&c1, &c2)
+	}
+	if !m.Over() || m.Winner(&c1, &c2) != "b" {
+		t.Errorf("Got over %t winner %s after 3 of 5 rounds won by b",
+			m.Over(), m.Winner(&c1, &c2))
+	}
+	if s := m.Summary(&c1, &c2); !strings.HasPrefix(s,
+		"b wins the match 3-0\n") {
+		t.Errorf("Got summary %q", s)
+	}
+}
+
+// TestSingleRound checks that matches stored before rounds end after one
+// round without describing it
+func TestSingleRound(t *testing.T) {
+	c1 := Class{PlayerName: "a", Health: 0}
+	c2 := Class{PlayerName: "b", Health: 50}
+	m := Match{}
+
+	if res := m.EndRound(&c1, &c2); res != "" || !m.Over() || c1.Health != 0 {
+		t.Errorf("Got text %q over %t health %d, expected a finished"+
+			" match", res, m.Over(), c1.Health)
+	}
+}
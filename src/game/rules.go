@@ -0,0 +1,136 @@
+package game
+
+import "fmt"
+
+// side is a character making a move in a turn, the amount it rolled, how
+// its attack landed and where the damage it deals is added up
+type side struct {
+	c     *Class
+	m     MoveDef
+	roll  int
+	kind  hitKind
+	dealt *int
+}
+
+// rule applies the outcome of side a moving against side b. It returns
+// whether the moves of a and b succeeded and a description of what happened
+type rule func(a, b side) (bool, bool, string)
+
+// rules holds the rule of every pair of outcomes with an attack first, and
+// of defenses against items. A pair the other way round is resolved by the
+// rule of the mirrored pair and nothing happens when both characters defend
+var rules = map[[2]string]rule{
+	{ATTACK, ATTACK}:  exchange,
+	{ATTACK, REPAIR}:  repairArmor,
+	{ATTACK, COUNTER}: counterAttack,
+	{ATTACK, HEAL}:    healDamage,
+
+	{ATTACK, itemOutcome}:  landAttack,
+	{REPAIR, itemOutcome}:  defendItem,
+	{COUNTER, itemOutcome}: defendItem,
+	{HEAL, itemOutcome}:    defendItem,
+}
+
+// resolve applies the outcome of sides a and b moving against each other by
+// the rule of their outcomes
+func resolve(a, b side) (bool, bool, string) {
+	if r, ok := rules[[2]string{a.m.Outcome, b.m.Outcome}]; ok {
+		return r(a, b)
+	}
+	if r, ok := rules[[2]string{b.m.Outcome, a.m.Outcome}]; ok {
+		sb, sa, text := r(b, a)
+		return sa, sb, text
+	}
+	return false, false, "Nothing happens!\n"
+}
+
+// hit deals damage n of the move of a to b, the part of it getting past
+// shields and penetrating armor straight to health, and describes it
+func hit(a, b side, n int) string {
+	d := n
+	if !a.m.Pierce {
+		// anything left past the shield broke it, so handleDamage absorbs
+		// nothing more
+		d = absorb(b.c, d)
+	}
+	pen := COMBAT.penetration(a.c, a.m, d)
+	handleDamage(b.c, d-pen, a.m.Pierce)
+	b.c.Health -= pen
+	*a.dealt += n
+	return fmt.Sprintf("%s deals %d damage%s\n", a.c.PlayerName, n, a.kind)
+}
+
+// exchange lands every attack that hit when both characters attack
+func exchange(a, b side) (bool, bool, string) {
+	var text string
+	if a.roll > 0 {
+		text += hit(a, b, a.roll)
+	}
+	if b.roll > 0 {
+		text += hit(b, a, b.roll)
+	}
+	return a.roll > 0, b.roll > 0, text
+}
+
+// repairArmor repairs the armor of b if it defended successfully, otherwise
+// the attack of a lands
+func repairArmor(a, b side) (bool, bool, string) {
+	if b.roll > 0 {
+		b.c.Armor += b.roll
+		if b.c.Armor > b.c.ArmorCap() {
+			b.c.Armor = b.c.ArmorCap()
+		}
+		return false, true, fmt.Sprintf("%s repairs armor for %d points\n",
+			b.c.PlayerName, b.roll)
+	}
+	if a.roll > 0 {
+		return true, false, hit(a, b, a.roll)
+	}
+	return false, false, ""
+}
+
+// counterAttack reflects the attack of a along with the counter of b if b
+// defended successfully, otherwise the attack of a lands with the backfire
+// of the counter
+func counterAttack(a, b side) (bool, bool, string) {
+	if b.roll > 0 {
+		return false, true, hit(b, a, a.roll+b.roll)
+	}
+	if a.roll-b.roll > 0 {
+		return true, false, hit(a, b, a.roll-b.roll)
+	}
+	return false, false, ""
+}
+
+// healDamage heals b if it defended successfully, otherwise the attack of a
+// lands
+func healDamage(a, b side) (bool, bool, string) {
+	if b.roll > 0 {
+		b.c.Health += b.roll
+		if b.c.Health > b.c.HealthCap() {
+			b.c.Health = b.c.HealthCap()
+		}
+		return false, true, fmt.Sprintf("%s heals %d damage\n",
+			b.c.PlayerName, b.roll)
+	}
+	if a.roll > 0 {
+		return true, false, hit(a, b, a.roll)
+	}
+	return false, false, ""
+}
+
+// landAttack lands the attack of a on b, which used an item rather than
+// defending
+func landAttack(a, b side) (bool, bool, string) {
+	if a.roll > 0 {
+		return true, false, hit(a, b, a.roll)
+	}
+	return false, false, ""
+}
+
+// defendItem lets the defense of a fail for want of an attack while b uses
+// an item, which describes itself
+func defendItem(a, b side) (bool, bool, string) {
+	return false, false, fmt.Sprintf("%s has no attack to defend against\n",
+		a.c.PlayerName)
+}
@@ -0,0 +1,107 @@
+package game
+
+import "testing"
+
+// result is the health and armor of both characters after a turn
+type result [4]int
+
+// TestRuleTable resolves all 36 move pairs of characters without a class
+// once with every attack hitting and every defense failing and once with
+// every attack missing and every defense succeeding
+func TestRuleTable(t *testing.T) {
+	// attacks deal 20*.5+1.5 = 11 damage, defenses roll 4 and parries
+	// backfire for 4
+	var (
+		none      = result{50, 0, 50, 0}
+		trade     = result{39, 0, 39, 0}
+		hitE      = result{50, 0, 39, 0}
+		hitP      = result{39, 0, 50, 0}
+		backfireE = result{50, 0, 35, 0}
+		backfireP = result{35, 0, 50, 0}
+		repairE   = result{50, 0, 50, 4}
+		repairP   = result{50, 4, 50, 0}
+		counterE  = result{46, 0, 50, 0}
+		counterP  = result{50, 0, 46, 0}
+		healE     = result{50, 0, 54, 0}
+		healP     = result{54, 0, 50, 0}
+	)
+	cases := []struct {
+		name     string
+		d        Dice
+		expected [6][6]result
+	}{
+		{"hit", fixedDice{.9, 20}, [6][6]result{
+			{trade, trade, trade, hitE, backfireE, hitE},
+			{trade, trade, trade, hitE, backfireE, hitE},
+			{trade, trade, trade, hitE, backfireE, hitE},
+			{hitP, hitP, hitP, none, none, none},
+			{backfireP, backfireP, backfireP, none, none, none},
+			{hitP, hitP, hitP, none, none, none},
+		}},
+		{"miss", fixedDice{.1, 20}, [6][6]result{
+			{none, none, none, repairE, counterE, healE},
+			{none, none, none, repairE, counterE, healE},
+			{none, none, none, repairE, counterE, healE},
+			{repairP, repairP, repairP, none, none, none},
+			{counterP, counterP, counterP, none, none, none},
+			{healP, healP, healP, none, none, none},
+		}},
+	}
+
+	for _, c := range cases {
+		for m1 := HEAVY; m1 <= EVADE; m1++ {
+			for m2 := HEAVY; m2 <= EVADE; m2++ {
+				p := Class{Health: 50, Strength: .5, Dexterity: .5,
+					Intellect: .5}
+				e := p
+				resolveTurn(&p, &e, m1, m2, c.d)
+
+				got := result{p.Health, p.Armor, e.Health, e.Armor}
+				if got != c.expected[m1][m2] {
+					t.Errorf("%s: got %v for %s against %s, expected %v",
+						c.name, got, m1, m2, c.expected[m1][m2])
+				}
+			}
+		}
+	}
+}
+
+// TestRuleSymmetry checks that all 36 move pairs resolve the same whichever
+// character is the first player
+func TestRuleSymmetry(t *testing.T) {
+	p := Class{PlayerName: "p", Health: 60, Armor: 5, Strength: .8,
+		Dexterity: .3, Intellect: .6}
+	e := Class{PlayerName: "e", Health: 40, Armor: 10, Strength: .4,
+		Dexterity: .7, Intellect: .2}
+
+	for _, f := range []float32{.1, .35, .5, .65, .9} {
+		d := fixedDice{f, 20}
+		for m1 := HEAVY; m1 <= EVADE; m1++ {
+			for m2 := HEAVY; m2 <= EVADE; m2++ {
+				p1, e1 := p, e
+				_, end1 := resolveTurn(&p1, &e1, m1, m2, d)
+				p2, e2 := p, e
+				_, end2 := resolveTurn(&e2, &p2, m2, m1, d)
+
+				if p1 != p2 || e1 != e2 || end1 != end2 {
+					t.Errorf("Got %v %v and mirrored %v %v for %s against"+
+						" %s at %.2f", p1, e1, p2, e2, m1, m2, f)
+				}
+			}
+		}
+	}
+}
+
+// TestRuleOutcomes checks that every pair of outcomes has a rule with the
+// attack first or nothing happens between defenses
+func TestRuleOutcomes(t *testing.T) {
+	outcomes := []string{ATTACK, REPAIR, COUNTER, HEAL}
+	for _, o1 := range outcomes {
+		for _, o2 := range outcomes {
+			_, ok := rules[[2]string{o1, o2}]
+			if ok != (o1 == ATTACK) {
+				t.Errorf("Got rule %t for %s against %s", ok, o1, o2)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package game
+
+import "fmt"
+
+// maxTurns ends matches in which neither character is able to finish the
+// other as a draw
+const maxTurns = 200
+
+// frozenAgent hides the Learner methods of an agent so it only plays
+type frozenAgent struct {
+	Agent
+}
+
+// NewRandomClass generates a character of a random class
+func NewRandomClass(playerName string) Class {
+	return rollRandomClass(playerName, sharedDice{})
+}
+
+// rollRandomClass generates a character of a random class with dice d
+func rollRandomClass(playerName string, d Dice) Class {
+	return rollClass(classes[d.Intn(len(classes))], playerName, d)
+}
+
+// PlayMatch plays a match between agents a and b on random characters and
+// returns 1 if a won, -1 if b won and 0 on a draw. Learners observe every turn
+// under match key key
+func PlayMatch(a, b Agent, key string) int {
+	return playMatch(a, b, key, sharedDice{})
+}
+
+// playMatch plays a match like PlayMatch rolling characters and turns with
+// dice d
+func playMatch(a, b Agent, key string, d Dice) int {
+	ca := rollRandomClass("a", d)
+	cb := rollRandomClass("b", d)
+
+	for i := 0; i < maxTurns; i++ {
+		// each agent controls e with its enemy as p
+		ma := legalTurn(a, &cb, &ca, d)
+		mb := legalTurn(b, &ca, &cb, d)
+
+		beforeA, beforeB := ca, cb
+		_, end := resolveTurn(&ca, &cb, ma, mb, d)
+		done := end || i == maxTurns-1
+
+		if l, ok := a.(Learner); ok {
+			l.Observe(key+"a", &beforeB, &beforeA, ma, &cb, &ca, done)
+		}
+		if l, ok := b.(Learner); ok {
+			l.Observe(key+"b", &beforeA, &beforeB, mb, &ca, &cb, done)
+		}
+
+		if end {
+			break
+		}
+	}
+
+	switch {
+	case ca.Health > 0 && cb.Health <= 0:
+		return 1
+	case cb.Health > 0 && ca.Health <= 0:
+		return -1
+	}
+	return 0
+}
+
+// SelfPlay trains learner l by playing episodes matches against opponent
+func SelfPlay(l Learner, opponent Agent, episodes int) {
+	for i := 0; i < episodes; i++ {
+		PlayMatch(l, opponent, fmt.Sprintf("selfplay%d", i))
+	}
+}
+
+// Evaluate plays matches between a and b without learning and returns the
+// fraction of matches won by a
+func Evaluate(a, b Agent, matches int) float32 {
+	var wins int
+	for i := 0; i < matches; i++ {
+		if PlayMatch(frozenAgent{a}, frozenAgent{b}, "") == 1 {
+			wins++
+		}
+	}
+	return float32(wins) / float32(matches)
+}
@@ -0,0 +1,71 @@
+package game
+
+// CanAfford returns whether c has enough stamina to play move m. Characters
+// created before stamina was added have no max stamina and can play anything
+func (c *Class) CanAfford(m Move) bool {
+	return c.MaxStamina == 0 || c.Stamina >= MoveOf(c, m).Cost
+}
+
+// exhausted returns whether c has too little stamina for a heavy attack
+func (c *Class) exhausted() bool {
+	return !c.CanAfford(HEAVY)
+}
+
+// spendStamina takes the cost of move m from the stamina of c, keeping it
+// between 0 and the max stamina of c
+func (c *Class) spendStamina(m Move) {
+	if c.MaxStamina == 0 {
+		return
+	}
+	c.Stamina -= MoveOf(c, m).Cost
+	if c.Stamina < 0 {
+		c.Stamina = 0
+	}
+	if c.Stamina > c.MaxStamina {
+		c.Stamina = c.MaxStamina
+	}
+}
+
+// LegalMoves returns the moves c has enough stamina to play
+func LegalMoves(c *Class) []Move {
+	var moves []Move
+	for m := HEAVY; m <= EVADE; m++ {
+		if c.CanAfford(m) {
+			moves = append(moves, m)
+		}
+	}
+	return moves
+}
+
+// bestLegal returns the best scored move c can afford, or a random one with
+// dice d if there are no scores
+func bestLegal(c *Class, scores []float32, d Dice) Move {
+	legal := LegalMoves(c)
+	if len(scores) != 6 {
+		return legal[d.Intn(len(legal))]
+	}
+
+	best := legal[0]
+	for _, m := range legal {
+		if scores[m] > scores[best] {
+			best = m
+		}
+	}
+	return best
+}
+
+// legalTurn gets the move of agent a controlling e against p. A move e can't
+// afford is replaced by the best scored move it can, or by a random one for
+// agents which can't score moves
+func legalTurn(a Agent, p, e *Class, d Dice) Move {
+	m := a.GetTurn(p, e)
+	if e.CanAfford(m) {
+		return m
+	}
+
+	var scores []float32
+	if s, ok := a.(Scorer); ok {
+		scores = s.Scores(p, e)
+	}
+	return bestLegal(e, scores, d)
+}
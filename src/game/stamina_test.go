@@ -0,0 +1,72 @@
+package game
+
+import "testing"
+
+// TestStaminaCosts checks that attacks spend stamina, defenses regenerate it
+// up to the max and exhausted characters can only afford cheap moves
+func TestStaminaCosts(t *testing.T) {
+	p := Class{Health: 100, Stamina: 30, MaxStamina: 60}
+	e := Class{Health: 100, Stamina: 55, MaxStamina: 60}
+	miss := fixedDice{0, 0}
+
+	resolveTurn(&p, &e, HEAVY, BLOCK, miss)
+	if p.Stamina != 5 || e.Stamina != 60 {
+		t.Errorf("Got stamina %d and %d, expected 5 and 60", p.Stamina,
+			e.Stamina)
+	}
+
+	expected := []Move{BLOCK, PARRY, EVADE}
+	legal := LegalMoves(&p)
+	if len(legal) != len(expected) {
+		t.Fatalf("Got legal moves %v, expected %v", legal, expected)
+	}
+	for i, m := range legal {
+		if m != expected[i] {
+			t.Errorf("Got legal moves %v, expected %v", legal, expected)
+		}
+	}
+
+	// an attack that can't be afforded misses and drains what is left
+	e.Armor = 0
+	resolveTurn(&p, &e, QUICK, HEAVY, miss)
+	if p.Stamina != 0 || e.Health != 100 {
+		t.Errorf("Got stamina %d and enemy health %d after an unaffordable"+
+			" attack, expected 0 and 100", p.Stamina, e.Health)
+	}
+}
+
+// TestNoStamina checks that characters without max stamina can play every
+// move
+func TestNoStamina(t *testing.T) {
+	c := Class{Health: 100}
+	if len(LegalMoves(&c)) != 6 {
+		t.Errorf("Got legal moves %v, expected all", LegalMoves(&c))
+	}
+	c.spendStamina(HEAVY)
+	if c.Stamina != 0 {
+		t.Errorf("Got stamina %d, expected 0", c.Stamina)
+	}
+}
+
+// TestLegalTurn checks that agents picking unaffordable moves are corrected
+// to their best scored affordable move
+func TestLegalTurn(t *testing.T) {
+	p := mustClass("Knight", "p")
+	e := mustClass("Wizard", "e")
+	e.Stamina = 12
+
+	if m := legalTurn(fixedAgent{HEAVY}, &p, &e, sharedDice{}); !e.CanAfford(m) {
+		t.Errorf("Got unaffordable move %s", m)
+	}
+	scorer := Greedy{fixedScores{9, 8, 1, 2, 3, 4}}
+	if m := legalTurn(scorer, &p, &e, sharedDice{}); m != QUICK {
+		t.Errorf("Got move %s, expected Quick", m)
+	}
+}
+
+// fixedScores scores moves the same in every state
+type fixedScores []float32
+
+func (s fixedScores) Scores(p, e *Class) []float32 {
+	return s
+}
@@ -0,0 +1,169 @@
+package game
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// SweepParams are the hyperparameters of one trial of a sweep
+type SweepParams struct {
+	LearningRate float32
+	Discount     float32
+	Explore      ExploreKind
+	ExploreRate  float32
+	Reward       string
+}
+
+// SweepSpace is the set of values a sweep searches over
+type SweepSpace struct {
+	LearningRates []float32
+	Discounts     []float32
+	Explores      []ExploreKind
+	ExploreRates  []float32
+	Rewards       []string
+}
+
+// SweepResult is the outcome of training a table with one set of parameters
+type SweepResult struct {
+	Params   SweepParams
+	VsRand   float32 // win rate of the trained table against rand
+	VsMinMax float32 // win rate of the trained table against minmax
+	Table    QTable
+	Table2   QTable // second estimate of double q-learning, nil otherwise
+}
+
+// Score is the mean win rate of the result against both reference agents
+func (r SweepResult) Score() float32 {
+	return (r.VsRand + r.VsMinMax) / 2
+}
+
+// Grid returns every combination of the values in the space, policies which
+// ignore the explore rate are tried once with a rate of 0
+func (s SweepSpace) Grid() []SweepParams {
+	var trials []SweepParams
+	for _, lr := range s.LearningRates {
+		for _, df := range s.Discounts {
+			for _, ex := range s.Explores {
+				rates := s.ExploreRates
+				if !ex.UsesRate() {
+					rates = []float32{0}
+				}
+				for _, er := range rates {
+					for _, rw := range s.Rewards {
+						trials = append(trials, SweepParams{lr, df, ex, er,
+							rw})
+					}
+				}
+			}
+		}
+	}
+	return trials
+}
+
+// Random returns n trials with the learning rate sampled log uniformly and
+// discount and explore rate uniformly between the smallest and largest value
+// in the space, explore policy and reward preset are picked at random. The
+// explore rate is 0 for policies which ignore it
+func (s SweepSpace) Random(n int) []SweepParams {
+	trials := make([]SweepParams, n)
+	for i := range trials {
+		lo, hi := span(s.LearningRates)
+		lr := math.Exp(rand.Float64()*(math.Log(float64(hi))-
+			math.Log(float64(lo))) + math.Log(float64(lo)))
+
+		dlo, dhi := span(s.Discounts)
+		elo, ehi := span(s.ExploreRates)
+		trials[i] = SweepParams{
+			LearningRate: float32(lr),
+			Discount:     dlo + rand.Float32()*(dhi-dlo),
+			Explore:      s.Explores[rand.Intn(len(s.Explores))],
+			ExploreRate:  elo + rand.Float32()*(ehi-elo),
+			Reward:       s.Rewards[rand.Intn(len(s.Rewards))],
+		}
+		if !trials[i].Explore.UsesRate() {
+			trials[i].ExploreRate = 0
+		}
+	}
+	return trials
+}
+
+// span returns the smallest and largest of vals
+func span(vals []float32) (float32, float32) {
+	lo, hi := vals[0], vals[0]
+	for _, v := range vals {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	return lo, hi
+}
+
+// agent creates a reinforcement agent with an empty table using the trial
+// parameters and update rule rule
+func (p SweepParams) agent(rule UpdateRule) *Reinforcement {
+	a := NewReinforcement(make(QTable), rule)
+	a.LearningRate = p.LearningRate
+	a.Discount = p.Discount
+	a.Explore = newExplorePolicy(p.Explore, p.ExploreRate)
+	a.Reward = RewardPresets[p.Reward]
+	a.Metrics = nil
+	return a
+}
+
+// Sweep trains a table by self-play against the opponent created by opponent
+// for every trial, running workers trials at a time, and evaluates each table
+// against rand and minmax. report is called as each trial finishes. Results
+// are returned best first
+func Sweep(
+	trials []SweepParams, rule UpdateRule, opponent func() Agent,
+	episodes, matches, workers int, report func(SweepResult),
+) []SweepResult {
+	jobs := make(chan SweepParams)
+	results := make(chan SweepResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				a := p.agent(rule)
+				SelfPlay(a, opponent(), episodes)
+
+				policy := Greedy{Scorer: a}
+				results <- SweepResult{
+					Params:   p,
+					VsRand:   Evaluate(policy, randAgent{}, matches),
+					VsMinMax: Evaluate(policy, minMaxAgent{}, matches),
+					Table:    a.QT,
+					Table2:   a.QT2,
+				}
+			}
+		}()
+	}
+
+	go func() {
+		for _, p := range trials {
+			jobs <- p
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	var ranked []SweepResult
+	for r := range results {
+		report(r)
+		ranked = append(ranked, r)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		return ranked[i].Score() > ranked[j].Score()
+	})
+	return ranked
+}
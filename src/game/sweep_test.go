@@ -0,0 +1,85 @@
+package game
+
+import "testing"
+
+var testSpace = SweepSpace{
+	LearningRates: []float32{.01, .1},
+	Discounts:     []float32{.3, .9},
+	Explores:      []ExploreKind{EXPLORE_CONSTANT, EXPLORE_LINEAR},
+	ExploreRates:  []float32{.05},
+	Rewards:       []string{"default", "aggressive", "defensive"},
+}
+
+// TestSweepGrid checks that the grid tries every combination
+func TestSweepGrid(t *testing.T) {
+	if n := len(testSpace.Grid()); n != 24 {
+		t.Errorf("Got %d trials, expected 24", n)
+	}
+}
+
+// TestSweepRandom checks that random trials stay within the space
+func TestSweepRandom(t *testing.T) {
+	for _, p := range testSpace.Random(nClassTests) {
+		if p.LearningRate < .01 || p.LearningRate > .1 {
+			t.Errorf("Learning rate %f out of bounds", p.LearningRate)
+		}
+		if p.Discount < .3 || p.Discount > .9 {
+			t.Errorf("Discount %f out of bounds", p.Discount)
+		}
+		if p.ExploreRate != .05 {
+			t.Errorf("Got explore rate %f, expected .05", p.ExploreRate)
+		}
+		if _, ok := RewardPresets[p.Reward]; !ok {
+			t.Errorf("Unknown reward preset %s", p.Reward)
+		}
+	}
+}
+
+// TestSweepRanked checks that every trial is run and results are ranked
+func TestSweepRanked(t *testing.T) {
+	trials := testSpace.Random(4)
+	var reported int
+	results := Sweep(trials, QLearning{}, func() Agent { return randAgent{} },
+		10, 10, 2, func(SweepResult) { reported++ })
+
+	if len(results) != len(trials) || reported != len(trials) {
+		t.Fatalf("Got %d results and %d reports, expected %d", len(results),
+			reported, len(trials))
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i].Score() > results[i-1].Score() {
+			t.Errorf("Result %d scored above result %d", i, i-1)
+		}
+	}
+}
+
+// TestSweepDoubleQ checks that double q-learning trials keep both tables
+// their greedy policy was scored with
+func TestSweepDoubleQ(t *testing.T) {
+	results := Sweep(testSpace.Random(1), DoubleQ{},
+		func() Agent { return randAgent{} }, 10, 10, 1,
+		func(SweepResult) {})
+	if len(results) != 1 || results[0].Table2 == nil {
+		t.Errorf("Got results %v, expected a second table", results)
+	}
+}
+
+// TestSweepIgnoredRates checks that policies which ignore the explore rate
+// are tried once per combination rather than once per rate
+func TestSweepIgnoredRates(t *testing.T) {
+	space := testSpace
+	space.Explores = []ExploreKind{EXPLORE_CONSTANT, EXPLORE_BOLTZMANN}
+	space.ExploreRates = []float32{.05, .2}
+
+	// 12 constant trials for each rate and 12 boltzmann trials
+	trials := space.Grid()
+	if len(trials) != 36 {
+		t.Errorf("Got %d trials, expected 36", len(trials))
+	}
+	for _, p := range append(trials, space.Random(nClassTests)...) {
+		if p.Explore == EXPLORE_BOLTZMANN && p.ExploreRate != 0 {
+			t.Errorf("Got boltzmann trial with explore rate %f",
+				p.ExploreRate)
+		}
+	}
+}
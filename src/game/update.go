@@ -0,0 +1,153 @@
+package game
+
+import "errors"
+
+// traceCutoff is the eligibility below which traces are dropped
+const traceCutoff float32 = .01
+
+// Transition is one observed turn from the perspective of the AI
+type Transition struct {
+	State      uint32
+	Action     Move
+	Reward     float32
+	NextState  uint32
+	NextAction Move // move played from NextState, unset when Done
+	Done       bool
+}
+
+// Episode holds learning state carried between the turns of one match
+type Episode struct {
+	pending *Transition
+	Traces  map[StateAction]float32
+}
+
+// StateAction indexes a single value of a qtable
+type StateAction struct {
+	State  uint32
+	Action Move
+}
+
+// UpdateRule updates the qtables of a reinforcement agent from a transition.
+// Update is always called with the agent locked
+type UpdateRule interface {
+	Update(a *Reinforcement, ep *Episode, t Transition)
+}
+
+// QLearning is one step off-policy q-learning
+type QLearning struct{}
+
+// Sarsa is one step on-policy sarsa using the move actually played next
+type Sarsa struct{}
+
+// ExpectedSarsa is sarsa using the expected value of the next state under
+// the exploration policy of the agent
+type ExpectedSarsa struct{}
+
+// DoubleQ is double q-learning, keeping two estimates where one selects the
+// best next move and the other evaluates it
+type DoubleQ struct{}
+
+// QLambda is watkins q(lambda) with eligibility traces that are cut whenever
+// an exploratory move is played
+type QLambda struct {
+	Lambda float32
+}
+
+// ParseUpdateRule converts an update rule name into an UpdateRule
+func ParseUpdateRule(s string) (UpdateRule, error) {
+	switch s {
+	case "q":
+		return QLearning{}, nil
+	case "sarsa":
+		return Sarsa{}, nil
+	case "expected-sarsa":
+		return ExpectedSarsa{}, nil
+	case "double-q":
+		return DoubleQ{}, nil
+	case "q-lambda":
+		return QLambda{Lambda: Lambda}, nil
+	}
+	return nil, errors.New("Could not parse update rule")
+}
+
+// target returns the td target of t given the value of the next state
+func (a *Reinforcement) target(t Transition, next float32) float32 {
+	if t.Done {
+		return t.Reward
+	}
+	return t.Reward + a.Discount*next
+}
+
+// learn moves the value of t.Action in t.State of qt toward target
+func (a *Reinforcement) learn(qt QTable, t Transition, target float32) {
+	row := qt.row(t.State)
+	qv := row[t.Action]
+	row[t.Action] = qv + a.LearningRate*(target-qv)
+	a.Metrics.tdError(target - qv)
+}
+
+func (QLearning) Update(a *Reinforcement, ep *Episode, t Transition) {
+	next := a.QT.get(t.NextState)
+	a.learn(a.QT, t, a.target(t, next[bestMove(next)]))
+}
+
+func (Sarsa) Update(a *Reinforcement, ep *Episode, t Transition) {
+	next := a.QT.get(t.NextState)
+	a.learn(a.QT, t, a.target(t, next[t.NextAction]))
+}
+
+func (ExpectedSarsa) Update(a *Reinforcement, ep *Episode, t Transition) {
+	next := a.QT.get(t.NextState)
+
+	var expected float32
+	for i, p := range a.Explore.Probs(t.NextState, next) {
+		expected += p * next[i]
+	}
+
+	a.learn(a.QT, t, a.target(t, expected))
+}
+
+func (DoubleQ) Update(a *Reinforcement, ep *Episode, t Transition) {
+	if a.QT2 == nil {
+		a.QT2 = a.QT.Copy()
+	}
+
+	// randomly pick which estimate to update
+	qa, qb := a.QT, a.QT2
+	if a.dice().Intn(2) == 0 {
+		qa, qb = qb, qa
+	}
+	m := bestMove(qa.get(t.NextState))
+	a.learn(qa, t, a.target(t, qb.get(t.NextState)[m]))
+	// both estimates cover the same states, unseen values staying 0
+	qb.row(t.State)
+}
+
+func (r QLambda) Update(a *Reinforcement, ep *Episode, t Transition) {
+	if ep.Traces == nil {
+		ep.Traces = make(map[StateAction]float32)
+	}
+
+	next := a.QT.get(t.NextState)
+	best := bestMove(next)
+	delta := a.target(t, next[best]) - a.QT.get(t.State)[t.Action]
+
+	ep.Traces[StateAction{t.State, t.Action}] += 1
+	for sa, e := range ep.Traces {
+		a.QT.row(sa.State)[sa.Action] += a.LearningRate * delta * e
+	}
+
+	// traces only carry over while the agent keeps playing greedily
+	if t.Done || next[t.NextAction] < next[best] {
+		ep.Traces = nil
+		return
+	}
+	for sa, e := range ep.Traces {
+		e *= a.Discount * r.Lambda
+		if e < traceCutoff {
+			delete(ep.Traces, sa)
+		} else {
+			ep.Traces[sa] = e
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package game
+
+import "testing"
+
+// TestUpdateRules checks that every update rule moves the value of a
+// terminal transition toward its reward
+func TestUpdateRules(t *testing.T) {
+	rules := map[string]UpdateRule{
+		"q":              QLearning{},
+		"sarsa":          Sarsa{},
+		"expected-sarsa": ExpectedSarsa{},
+		"double-q":       DoubleQ{},
+		"q-lambda":       QLambda{Lambda: .8},
+	}
+	tr := Transition{State: 1, Action: PARRY, Reward: 2, NextState: 2, Done: true}
+
+	for name, rule := range rules {
+		a := NewReinforcement(make(QTable), rule)
+		a.LearningRate = .5
+		a.Discount = .3
+		rule.Update(a, &Episode{}, tr)
+
+		v := a.QT.get(tr.State)[tr.Action]
+		if a.QT2 != nil {
+			v += a.QT2.get(tr.State)[tr.Action]
+		}
+		if v != 1 {
+			t.Errorf("Got value %f for rule %s, expected 1", v, name)
+		}
+	}
+}
+
+// TestParseUpdateRule checks that every rule name is recognised
+func TestParseUpdateRule(t *testing.T) {
+	for _, name := range []string{
+		"q", "sarsa", "expected-sarsa", "double-q", "q-lambda",
+	} {
+		_, err := ParseUpdateRule(name)
+		if err != nil {
+			t.Errorf("Could not parse rule %s", name)
+		}
+	}
+	_, err := ParseUpdateRule("unknown")
+	if err == nil {
+		t.Errorf("Expected error parsing unknown rule")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.iu.edu/evogelsa/go-ml-rpg/game"
+	"github.iu.edu/evogelsa/go-ml-rpg/web"
+)
+
+// imitateCmd trains a qtable or policy table by behaviour cloning the moves
+// human players made in the recorded matches
+func imitateCmd(args []string) {
+	fs := flag.NewFlagSet("imitate", flag.ExitOnError)
+	records := fs.String("records", web.RECORD_DIR, "Directory of recorded"+
+		" matches to learn from\n\t")
+	model := fs.String("model", "qtable", "Model to train. Options:"+
+		"\n\tqtable\n\tpolicy\n\t")
+	out := fs.String("out", "", "File the model is saved to, defaults to"+
+		" qtable or policy depending on model\n\t")
+	wins := fs.Bool("wins", false, "Only learn from matches the player"+
+		" won\n\t")
+	scale := fs.Float64("scale", 1, "Value given to a move always played by"+
+		" humans when cloning into a qtable\n\t")
+	loadRules := rulesFlags(fs)
+
+	fs.Parse(args)
+	err := loadRules()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		*out = *model
+	}
+
+	recs, err := game.LoadRecords(*records)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Printf("Loaded %d recorded turns\n", len(recs))
+
+	switch *model {
+	case "qtable":
+		qt := game.CloneQTable(recs, *wins, float32(*scale))
+		fmt.Printf("Cloned %d states\n", len(qt))
+		err = qt.Save(*out)
+	case "policy":
+		pt := game.ClonePolicy(recs, *wins)
+		fmt.Printf("Cloned %d states\n", len(pt))
+		err = pt.Save(*out)
+	default:
+		fmt.Println("Model unrecognized, run with flag -h for help")
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
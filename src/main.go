@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"strings"
 	"time"
 
 	"github.iu.edu/evogelsa/go-ml-rpg/game"
@@ -14,27 +15,232 @@ import (
 
 const PORT = ":8081"
 
+// reinforcementFlags defines the flags configuring the reinforcement model on
+// fs and returns a function which applies them once fs is parsed
+func reinforcementFlags(fs *flag.FlagSet) func() error {
+	lr := fs.Float64("lr", .05, "Learning rate that reinforcement model"+
+		" will use\n\t")
+	df := fs.Float64("df", .3, "Discount factor that reinforcement model"+
+		" will use\n\t")
+	er := fs.Float64("er", .05, "Explore rate that reinforcement model"+
+		" will start with\n\t")
+	explore := fs.String("explore", "constant", "Exploration policy that"+
+		" reinforcement model will use. Options:\n\tconstant\n\tlinear"+
+		"\n\texp\n\tboltzmann\n\tucb\n\t")
+	erMin := fs.Float64("erMin", .01, "Lowest explore rate the linear and"+
+		" exp policies decay to\n\t")
+	erDecay := fs.Float64("erDecay", .001, "Explore rate the linear policy"+
+		" subtracts per match\n\t")
+	erExpDecay := fs.Float64("erExpDecay", .999, "Factor the exp policy"+
+		" multiplies the explore rate by per match\n\t")
+	temp := fs.Float64("temp", 1, "Temperature of the boltzmann"+
+		" policy\n\t")
+	ucb := fs.Float64("ucb", 1, "Exploration bonus weight of the ucb"+
+		" policy\n\t")
+	dqnLr := fs.Float64("dqnLr", .001, "Learning rate that dqn model will"+
+		" use\n\t")
+	hidden := fs.Int("hidden", 32, "Size of the two hidden layers of new dqn"+
+		" networks\n\t")
+	replay := fs.Int("replay", 10000, "Number of turns kept in the dqn replay"+
+		" buffer\n\t")
+	batch := fs.Int("batch", 32, "Number of turns in each dqn training"+
+		" batch\n\t")
+	plan := fs.Int("plan", 0, "Number of simulated dyna-q planning updates"+
+		" the reinforcement model makes per real turn\n\t")
+	sync := fs.Int("sync", 500, "Number of dqn training steps between target"+
+		" network updates\n\t")
+	lambda := fs.Float64("lambda", .8, "Trace decay that the q-lambda update"+
+		" rule will use\n\t")
+	rule := fs.String("rule", "q", "Update rule that reinforcement model will"+
+		" use. Options:\n\tq\n\tsarsa\n\texpected-sarsa\n\tdouble-q"+
+		"\n\tq-lambda\n\t")
+	reward := fs.String("reward", "default", "Reward preset that"+
+		" reinforcement model will use. Options:\n\tdefault\n\taggressive"+
+		"\n\tdefensive\n\tbalanced\n\t")
+	metrics := fs.String("metrics", "", "File training telemetry is appended"+
+		" to as json lines, empty to record none\n\t")
+
+	return func() error {
+		game.LearningRate = float32(*lr)
+		game.Discount = float32(*df)
+		game.ExploreRate = float32(*er)
+		game.ExploreMin = float32(*erMin)
+		game.ExploreDecay = float32(*erDecay)
+		game.ExploreExpDecay = float32(*erExpDecay)
+		game.Temperature = float32(*temp)
+		game.UCBWeight = float32(*ucb)
+		game.Lambda = float32(*lambda)
+		game.DQNLearningRate = float32(*dqnLr)
+		game.HiddenSize = *hidden
+		game.ReplaySize = *replay
+		game.BatchSize = *batch
+		game.SyncEvery = *sync
+		game.PlanningSteps = *plan
+		if *replay < 1 || *batch < 1 || *sync < 1 {
+			return errors.New("The dqn replay buffer, batches and target" +
+				" syncs need at least 1 turn")
+		}
+		if *replay < *batch {
+			return errors.New("The dqn replay buffer has to hold at least" +
+				" a batch of turns")
+		}
+
+		r, err := game.ParseUpdateRule(*rule)
+		if err != nil {
+			return err
+		}
+		game.UPDATE_RULE = r
+
+		k, err := game.ParseExploreKind(*explore)
+		if err != nil {
+			return err
+		}
+		if err = game.ValidateExplore(k); err != nil {
+			return err
+		}
+		game.EXPLORE_POLICY = k
+
+		game.REWARD, err = game.ParseRewardPreset(*reward)
+		if err != nil {
+			return err
+		}
+
+		if *metrics != "" {
+			f, err := os.OpenFile(*metrics,
+				os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+			if err != nil {
+				return err
+			}
+			game.METRICS = game.NewTelemetry(f)
+		}
+		return nil
+	}
+}
+
+// rulesFlags defines the flags selecting the move, class and item files and
+// the combat model on fs and returns a function which loads them once fs is
+// parsed
+func rulesFlags(fs *flag.FlagSet) func() error {
+	movesFn := fs.String("moves", game.MOVES_FILE, "File the moves of the"+
+		" character classes are loaded from\n\t")
+	fn := fs.String("classes", game.CLASSES_FILE, "File the character"+
+		" classes are loaded from\n\t")
+	itemsFn := fs.String("items", game.ITEMS_FILE, "File the items found"+
+		" after victories are loaded from\n\t")
+	combat := fs.String("combat", "classic", "Combat model rolling attack"+
+		" damage. Options:\n\tclassic, no crits or glancing blows"+
+		"\n\tstandard\n\tswingy\n\t")
+
+	return func() error {
+		var err error
+		game.COMBAT, err = game.ParseCombatModel(*combat)
+		if err != nil {
+			return err
+		}
+
+		// classes are checked against the moves so those are loaded first
+		err = game.LoadMoves(*movesFn)
+		if err != nil {
+			return err
+		}
+		err = game.LoadClasses(*fn)
+		if err != nil {
+			return err
+		}
+		return game.LoadItems(*itemsFn)
+	}
+}
+
 func main() {
+	// subcommands are selected by the first argument
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "train":
+			trainCmd(os.Args[2:])
+			return
+		case "evolve":
+			evolveCmd(os.Args[2:])
+			return
+		case "imitate":
+			imitateCmd(os.Args[2:])
+			return
+		case "metrics":
+			metricsCmd(os.Args[2:])
+			return
+		case "sweep":
+			sweepCmd(os.Args[2:])
+			return
+		case "merge":
+			mergeCmd(os.Args[2:])
+			return
+		}
+	}
+
 	// define ai flag with default minmax
 	aiAlg := flag.String("ai", "reinforcement",
 		"Specifies algorithm AI will use. Options:"+
-			"\n\trand\n\tminmax\n\treinforcement\n\t")
-	lr := flag.Float64("lr", .05, "Learning rate that reinforcement model"+
-		" will use\n\t")
-	df := flag.Float64("df", .3, "Discount factor that reinforcement model"+
-		" will use\n\t")
-	er := flag.Float64("er", .05, "Explore rate that reinforcement model"+
-		" will use\n\t")
+			"\n\trand\n\tminmax\n\treinforcement\n\tdqn\n\tpolicy\n\tbandit"+
+			"\n\t")
+	profile := flag.String("profile", "", "Name of the minmax profile in "+
+		game.PROFILE_DIR+" that minmax will use\n\t")
+	applyRL := reinforcementFlags(flag.CommandLine)
+	loadRules := rulesFlags(flag.CommandLine)
+	target := flag.Float64("target", .5, "Player win rate that adaptive"+
+		" difficulty will aim for\n\t")
+	perPlayer := flag.Bool("perPlayer", false, "Reinforcement model keeps a"+
+		" separate qtable for every player if true\n\t")
 	train := flag.Bool("train", false, "Reinforcement model will update after"+
 		" each move if true\n\t")
+	arms := flag.String("arms", "rand,minmax,reinforcement", "Comma"+
+		" separated strategies the bandit AI chooses between\n\t")
+	objective := flag.String("objective", "challenge", "What the bandit AI"+
+		" picks strategies for. Options:\n\tchallenge, close matches"+
+		"\n\twin\n\t")
+	rounds := flag.Int("rounds", 1, "Number of rounds matches are played"+
+		" over, the first character to win most of them wins the match\n\t")
+	counter := flag.Bool("counter", false, "AI switches to a counter strategy"+
+		" against players caught using a degenerate one if true\n\t")
 
 	// parse flags
 	flag.Parse()
 
-	game.LearningRate = float32(*lr)
-	game.Discount = float32(*df)
-	game.ExploreRate = float32(*er)
+	err := applyRL()
+	if err == nil {
+		err = loadRules()
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 	game.Train = *train
+	game.PerPlayer = *perPlayer
+	game.CounterExploits = *counter
+	game.BanditArms = strings.Split(*arms, ",")
+	for _, arm := range game.BanditArms {
+		if err = game.ValidateArm(arm); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	game.BANDIT_OBJECTIVE, err = game.ParseBanditObjective(*objective)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *profile != "" {
+		prof, err := game.LoadMinMaxProfile(*profile)
+		if err != nil {
+			fmt.Println(errors.New("Cannot load profile " + *profile))
+			os.Exit(1)
+		}
+		game.PROFILE = &prof
+	}
+	game.TargetWinRate = float32(*target)
+	if *rounds < 1 {
+		fmt.Println(errors.New("Matches need at least 1 round"))
+		os.Exit(1)
+	}
+	game.ROUNDS = *rounds
 
 	// set algorithm accordingly to commandline flag
 	switch *aiAlg {
@@ -47,6 +253,15 @@ func main() {
 	case "reinforcement":
 		fmt.Println("Using AI Reinforcement")
 		game.AI_ALG = game.AI_REINFORCEMENT
+	case "dqn":
+		fmt.Println("Using AI DQN")
+		game.AI_ALG = game.AI_DQN
+	case "policy":
+		fmt.Println("Using AI Policy")
+		game.AI_ALG = game.AI_POLICY
+	case "bandit":
+		fmt.Println("Using AI Bandit")
+		game.AI_ALG = game.AI_BANDIT
 	default:
 		fmt.Println("AI Unrecognized, run with flag -h for help")
 		fmt.Println("Defaulting to AI Reinforcement")
@@ -54,7 +269,7 @@ func main() {
 	}
 
 	// check if directory structure exists and make where absent
-	_, err := os.Stat(web.SAVE_DIR)
+	_, err = os.Stat(web.SAVE_DIR)
 	if err != nil {
 		if os.IsNotExist(err) {
 			err := os.Mkdir(web.SAVE_DIR, 0777)
@@ -84,6 +299,36 @@ func main() {
 			}
 		}
 	}
+	_, err = os.Stat(web.RECORD_DIR)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err := os.Mkdir(web.RECORD_DIR, 0777)
+			if err != nil {
+				fmt.Println(errors.New("Cannot create RECORD_DIR"))
+				os.Exit(1)
+			}
+		}
+	}
+	_, err = os.Stat(web.EXPLAIN_DIR)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err := os.Mkdir(web.EXPLAIN_DIR, 0777)
+			if err != nil {
+				fmt.Println(errors.New("Cannot create EXPLAIN_DIR"))
+				os.Exit(1)
+			}
+		}
+	}
+	_, err = os.Stat(web.MATCH_DIR)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err := os.Mkdir(web.MATCH_DIR, 0777)
+			if err != nil {
+				fmt.Println(errors.New("Cannot create MATCH_DIR"))
+				os.Exit(1)
+			}
+		}
+	}
 	_, err = os.Stat(web.IMG_DIR)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.iu.edu/evogelsa/go-ml-rpg/game"
+)
+
+// mergeCmd combines qtables given as arguments, optionally together with
+// every per player table, into a single table
+func mergeCmd(args []string) {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: merge [flags] table[:weight] ...")
+		fs.PrintDefaults()
+	}
+	out := fs.String("out", "qtable", "File the merged table is saved to,"+
+		" visit counts are saved next to it\n\t")
+	byVisits := fs.Bool("visits", false, "Weigh every state by how often"+
+		" each table visited it\n\t")
+	players := fs.String("players", "", "Directory of per player tables to"+
+		" distill into the merged table, empty to use none\n\t")
+
+	fs.Parse(args)
+
+	var srcs []game.MergeSource
+	for _, arg := range fs.Args() {
+		fn, weight, err := parseSource(arg)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		src, err := game.LoadMergeSource(fn, weight)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		srcs = append(srcs, src)
+		fmt.Printf("%s: %d states, weight %.2f\n", fn, len(src.Table),
+			weight)
+	}
+
+	if *players != "" {
+		playerSrcs, err := game.LoadPlayerSources(*players)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		srcs = append(srcs, playerSrcs...)
+		fmt.Printf("%s: %d player tables\n", *players, len(playerSrcs))
+	}
+
+	if len(srcs) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	qt, visits := game.MergeTables(srcs, *byVisits)
+	err := qt.Save(*out)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	err = visits.Save(*out + "-visits")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Printf("saved %d states to %s\n", len(qt), *out)
+}
+
+// parseSource splits a table argument into its file and weight, which
+// defaults to 1
+func parseSource(arg string) (string, float32, error) {
+	i := strings.LastIndex(arg, ":")
+	if i < 0 {
+		return arg, 1, nil
+	}
+	w, err := strconv.ParseFloat(arg[i+1:], 32)
+	if err != nil {
+		return arg, 0, err
+	}
+	return arg[:i], float32(w), nil
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.iu.edu/evogelsa/go-ml-rpg/game"
+)
+
+// curve is one learning curve, y values over the episode they were recorded at
+type curve struct {
+	name string
+	x    []int
+	y    []float32
+}
+
+// metricsCmd summarizes a training telemetry file as ascii learning curves
+// and optionally an svg file
+func metricsCmd(args []string) {
+	fs := flag.NewFlagSet("metrics", flag.ExitOnError)
+	in := fs.String("in", "metrics.jsonl", "Telemetry file written by"+
+		" -metrics\n\t")
+	window := fs.Int("window", 100, "Number of episodes episode curves are"+
+		" averaged over\n\t")
+	width := fs.Int("width", 60, "Width of the ascii plots\n\t")
+	height := fs.Int("height", 10, "Height of the ascii plots\n\t")
+	svg := fs.String("svg", "", "File the curves are also drawn to as svg,"+
+		" empty to draw none\n\t")
+
+	fs.Parse(args)
+
+	recs, err := game.LoadMetrics(*in)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	curves := buildCurves(recs, *window)
+	for _, c := range curves {
+		plotASCII(os.Stdout, c, *width, *height)
+	}
+
+	if *svg != "" {
+		f, err := os.Create(*svg)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		plotSVG(f, curves)
+	}
+}
+
+// buildCurves turns telemetry records into learning curves, episode values
+// are smoothed with a moving average over window episodes
+func buildCurves(recs []game.MetricRecord, window int) []curve {
+	episode := []*curve{
+		{name: "return"},
+		{name: "td error"},
+		{name: "epsilon"},
+		{name: "states"},
+	}
+	winRates := make(map[string]*curve)
+
+	for _, r := range recs {
+		switch r.Kind {
+		case "episode":
+			vals := []float32{r.Return, r.TDError, r.Epsilon,
+				float32(r.States)}
+			for i, c := range episode {
+				c.x = append(c.x, r.Episode)
+				c.y = append(c.y, vals[i])
+			}
+		case "eval":
+			for ref, rate := range r.WinRate {
+				c, ok := winRates[ref]
+				if !ok {
+					c = &curve{name: "win rate vs " + ref}
+					winRates[ref] = c
+				}
+				c.x = append(c.x, r.Episode)
+				c.y = append(c.y, rate)
+			}
+		}
+	}
+
+	var curves []curve
+	for _, c := range episode {
+		if len(c.y) > 0 {
+			c.y = movingAverage(c.y, window)
+			curves = append(curves, *c)
+		}
+	}
+
+	var refs []string
+	for ref := range winRates {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		curves = append(curves, *winRates[ref])
+	}
+
+	return curves
+}
+
+// movingAverage returns the mean of each value and up to window-1 values
+// before it
+func movingAverage(y []float32, window int) []float32 {
+	if window < 1 {
+		window = 1
+	}
+	avg := make([]float32, len(y))
+	var sum float32
+	for i, v := range y {
+		sum += v
+		if i >= window {
+			sum -= y[i-window]
+		}
+		n := i + 1
+		if n > window {
+			n = window
+		}
+		avg[i] = sum / float32(n)
+	}
+	return avg
+}
+
+// bounds returns the smallest and largest of y ignoring NaN, widened when
+// equal so a curve can be scaled
+func bounds(y []float64) (float64, float64) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range y {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	if lo == hi {
+		lo, hi = lo-.5, hi+.5
+	}
+	return lo, hi
+}
+
+// columns averages the y values of c into n equally wide buckets of episodes,
+// buckets without values are NaN
+func (c curve) columns(n int) []float64 {
+	first, last := c.x[0], c.x[len(c.x)-1]
+	span := last - first + 1
+
+	sums := make([]float64, n)
+	counts := make([]int, n)
+	for i, x := range c.x {
+		col := (x - first) * n / span
+		sums[col] += float64(c.y[i])
+		counts[col]++
+	}
+	for i := range sums {
+		if counts[i] == 0 {
+			sums[i] = math.NaN()
+		} else {
+			sums[i] /= float64(counts[i])
+		}
+	}
+	return sums
+}
+
+// plotASCII draws c as a width by height character plot labelled with its
+// bounds and episode range
+func plotASCII(w io.Writer, c curve, width, height int) {
+	cols := c.columns(width)
+	lo, hi := bounds(cols)
+
+	grid := make([][]byte, height)
+	for i := range grid {
+		grid[i] = []byte(strings.Repeat(" ", width))
+	}
+	for x, v := range cols {
+		if math.IsNaN(v) {
+			continue
+		}
+		row := int((v-lo)/(hi-lo)*float64(height-1) + .5)
+		grid[height-1-row][x] = '*'
+	}
+
+	fmt.Fprintf(w, "%s\n", c.name)
+	for i, line := range grid {
+		label := ""
+		switch i {
+		case 0:
+			label = fmt.Sprintf("%.3f", hi)
+		case height - 1:
+			label = fmt.Sprintf("%.3f", lo)
+		}
+		fmt.Fprintf(w, "%9s |%s\n", label, line)
+	}
+	fmt.Fprintf(w, "%9s +%s\n", "", strings.Repeat("-", width))
+	last := c.x[len(c.x)-1]
+	fmt.Fprintf(w, "%9s  %-*d%d\n\n", "", width-len(fmt.Sprint(last)), c.x[0],
+		last)
+}
+
+// svg plot layout in pixels
+const (
+	svgWidth  = 600
+	svgHeight = 150
+	svgMargin = 40
+)
+
+// plotSVG draws every curve as a line chart stacked in one svg document
+func plotSVG(w io.Writer, curves []curve) {
+	total := len(curves) * (svgHeight + svgMargin)
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d"`+
+		` height="%d" font-family="monospace" font-size="12">`+"\n",
+		svgWidth+2*svgMargin, total+svgMargin)
+
+	for i, c := range curves {
+		top := svgMargin + i*(svgHeight+svgMargin)
+		y := make([]float64, len(c.y))
+		for j, v := range c.y {
+			y[j] = float64(v)
+		}
+		lo, hi := bounds(y)
+		first, last := c.x[0], c.x[len(c.x)-1]
+		span := float32(last - first)
+		if span == 0 {
+			span = 1
+		}
+
+		fmt.Fprintf(w, `<text x="%d" y="%d">%s</text>`+"\n", svgMargin,
+			top-8, c.name)
+		fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d"`+
+			` fill="none" stroke="#999"/>`+"\n", svgMargin, top, svgWidth,
+			svgHeight)
+		fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="end">%.3f</text>`+
+			"\n", svgMargin-4, top+12, hi)
+		fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="end">%.3f</text>`+
+			"\n", svgMargin-4, top+svgHeight, lo)
+		fmt.Fprintf(w, `<text x="%d" y="%d">%d</text>`+"\n", svgMargin,
+			top+svgHeight+14, first)
+		fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="end">%d</text>`+
+			"\n", svgMargin+svgWidth, top+svgHeight+14, last)
+
+		points := make([]string, len(y))
+		for j, v := range y {
+			px := float32(svgMargin) + float32(c.x[j]-first)/span*svgWidth
+			py := float64(top) + (hi-v)/(hi-lo)*svgHeight
+			points[j] = fmt.Sprintf("%.1f,%.1f", px, py)
+		}
+		fmt.Fprintf(w, `<polyline fill="none" stroke="#c33" points="%s"/>`+
+			"\n", strings.Join(points, " "))
+	}
+
+	fmt.Fprintln(w, "</svg>")
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.iu.edu/evogelsa/go-ml-rpg/game"
+)
+
+// sweepCmd searches over reinforcement hyperparameters by training a table
+// for every trial in parallel and ranking the tables by their win rate
+// against rand and minmax
+func sweepCmd(args []string) {
+	fs := flag.NewFlagSet("sweep", flag.ExitOnError)
+	lrs := fs.String("lr", ".01,.05,.2", "Comma separated learning rates"+
+		" to try\n\t")
+	dfs := fs.String("df", ".1,.3,.6,.9", "Comma separated discount factors"+
+		" to try\n\t")
+	explores := fs.String("explore", "constant,linear", "Comma separated"+
+		" exploration policies to try\n\t")
+	ers := fs.String("er", ".05,.2", "Comma separated starting explore"+
+		" rates to try\n\t")
+	rewards := fs.String("reward", "default,aggressive,defensive", "Comma"+
+		" separated reward presets to try\n\t")
+	search := fs.String("search", "grid", "How trials are chosen. Options:"+
+		"\n\tgrid\n\trandom, sampling between the smallest and largest"+
+		" values\n\t")
+	trials := fs.Int("trials", 20, "Number of trials of a random search\n\t")
+	episodes := fs.Int("episodes", 5000, "Number of matches each table is"+
+		" trained for\n\t")
+	opponent := fs.String("opponent", "rand", "Agent tables are trained"+
+		" against. Options:\n\trand\n\tminmax\n\t")
+	matches := fs.Int("matches", 500, "Number of matches played against"+
+		" each reference agent to evaluate a table\n\t")
+	workers := fs.Int("workers", runtime.NumCPU(), "Number of trials run in"+
+		" parallel\n\t")
+	rule := fs.String("rule", "q", "Update rule the tables are trained"+
+		" with. Options:\n\tq\n\tsarsa\n\texpected-sarsa\n\tdouble-q"+
+		"\n\tq-lambda\n\t")
+	erMin := fs.Float64("erMin", .01, "Lowest explore rate the linear and"+
+		" exp policies decay to\n\t")
+	erDecay := fs.Float64("erDecay", .001, "Explore rate the linear policy"+
+		" subtracts per match\n\t")
+	erExpDecay := fs.Float64("erExpDecay", .999, "Factor the exp policy"+
+		" multiplies the explore rate by per match\n\t")
+	temp := fs.Float64("temp", 1, "Temperature of the boltzmann"+
+		" policy\n\t")
+	ucb := fs.Float64("ucb", 1, "Exploration bonus weight of the ucb"+
+		" policy\n\t")
+	lambda := fs.Float64("lambda", .8, "Trace decay that the q-lambda update"+
+		" rule will use\n\t")
+	out := fs.String("out", "", "File the best table is saved to, empty to"+
+		" save none\n\t")
+	loadRules := rulesFlags(fs)
+
+	fs.Parse(args)
+	err := loadRules()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	rand.Seed(time.Now().Unix())
+
+	game.ExploreMin = float32(*erMin)
+	game.ExploreDecay = float32(*erDecay)
+	game.ExploreExpDecay = float32(*erExpDecay)
+	game.Temperature = float32(*temp)
+	game.UCBWeight = float32(*ucb)
+	game.Lambda = float32(*lambda)
+	r, err := game.ParseUpdateRule(*rule)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if _, err = game.NewAgent(*opponent); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	newOpponent := func() game.Agent {
+		a, _ := game.NewAgent(*opponent)
+		return a
+	}
+
+	space, err := parseSpace(*lrs, *dfs, *explores, *ers, *rewards)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	for _, k := range space.Explores {
+		if err = game.ValidateExplore(k); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	// random searches sample learning rates on a log scale
+	for _, lr := range space.LearningRates {
+		if lr <= 0 {
+			fmt.Println(errors.New("Learning rates have to be above 0"))
+			os.Exit(1)
+		}
+	}
+	if *workers < 1 || *matches < 1 {
+		fmt.Println(errors.New("Sweeps need at least 1 worker and 1" +
+			" evaluation match"))
+		os.Exit(1)
+	}
+
+	var params []game.SweepParams
+	switch *search {
+	case "grid":
+		params = space.Grid()
+	case "random":
+		params = space.Random(*trials)
+	default:
+		fmt.Println("Search unrecognized, run with flag -h for help")
+		os.Exit(1)
+	}
+
+	fmt.Printf("running %d trials on %d workers\n", len(params), *workers)
+	done := 0
+	results := game.Sweep(params, r, newOpponent, *episodes, *matches,
+		*workers, func(res game.SweepResult) {
+			done++
+			fmt.Printf("trial %d/%d: %s score %.3f\n", done, len(params),
+				formatParams(res.Params), res.Score())
+		})
+
+	fmt.Printf("\n%4s %-7s %-7s %-10s %-7s %-11s %7s %7s %7s\n", "rank",
+		"lr", "df", "explore", "er", "reward", "rand", "minmax", "score")
+	for i, res := range results {
+		p := res.Params
+		fmt.Printf("%4d %-7.4f %-7.3f %-10s %-7.3f %-11s %7.3f %7.3f %7.3f\n",
+			i+1, p.LearningRate, p.Discount, p.Explore, p.ExploreRate,
+			p.Reward, res.VsRand, res.VsMinMax, res.Score())
+	}
+
+	// both tables of double q-learning make up the policy that was ranked
+	if *out != "" && len(results) > 0 {
+		err = results[0].Table.Save(*out)
+		if err == nil && results[0].Table2 != nil {
+			err = results[0].Table2.Save(*out + "-qt2")
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+}
+
+// parseSpace converts the comma separated flag values into a sweep space
+func parseSpace(lrs, dfs, explores, ers, rewards string) (game.SweepSpace,
+	error) {
+	var space game.SweepSpace
+	var err error
+
+	if space.LearningRates, err = parseFloats(lrs); err != nil {
+		return space, err
+	}
+	if space.Discounts, err = parseFloats(dfs); err != nil {
+		return space, err
+	}
+	if space.ExploreRates, err = parseFloats(ers); err != nil {
+		return space, err
+	}
+	for _, s := range strings.Split(explores, ",") {
+		k, err := game.ParseExploreKind(s)
+		if err != nil {
+			return space, err
+		}
+		space.Explores = append(space.Explores, k)
+	}
+	for _, s := range strings.Split(rewards, ",") {
+		if _, err := game.ParseRewardPreset(s); err != nil {
+			return space, err
+		}
+		space.Rewards = append(space.Rewards, s)
+	}
+
+	return space, nil
+}
+
+// parseFloats converts a comma separated list of numbers
+func parseFloats(s string) ([]float32, error) {
+	var vals []float32
+	for _, f := range strings.Split(s, ",") {
+		v, err := strconv.ParseFloat(f, 32)
+		if err != nil {
+			return vals, err
+		}
+		vals = append(vals, float32(v))
+	}
+	return vals, nil
+}
+
+// formatParams returns a one line description of trial parameters
+func formatParams(p game.SweepParams) string {
+	return fmt.Sprintf("lr %.4f df %.3f %s er %.3f reward %s",
+		p.LearningRate, p.Discount, p.Explore, p.ExploreRate, p.Reward)
+}
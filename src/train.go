@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.iu.edu/evogelsa/go-ml-rpg/game"
+)
+
+// trainCmd trains a qtable or dqn network by self-play against an opponent
+// agent without running the server, evaluating it against rand and minmax as
+// it goes
+func trainCmd(args []string) {
+	fs := flag.NewFlagSet("train", flag.ExitOnError)
+	episodes := fs.Int("episodes", 10000, "Number of matches to train for\n\t")
+	opponent := fs.String("opponent", "rand", "Agent to train against."+
+		" Options:\n\trand\n\tminmax\n\t")
+	model := fs.String("model", "qtable", "Model to train. Options:"+
+		"\n\tqtable\n\tdqn\n\t")
+	in := fs.String("in", "", "File to start training from, defaults to"+
+		" qtable or dqn depending on model\n\t")
+	out := fs.String("out", "", "File trained model is saved to, defaults"+
+		" to in\n\t")
+	evalEvery := fs.Int("eval", 1000, "Number of matches between"+
+		" evaluations\n\t")
+	evalMatches := fs.Int("evalMatches", 500, "Number of matches played"+
+		" for each evaluation\n\t")
+	workers := fs.Int("workers", 1, "Number of goroutines playing matches"+
+		" in parallel, only used by the qtable model without"+
+		" -curriculum\n\t")
+	mergeEvery := fs.Int("merge", 100, "Number of matches each parallel"+
+		" worker plays between merging into the shared table\n\t")
+	curriculum := fs.Bool("curriculum", false, "Train against rand, then"+
+		" minmax, then a league of snapshots of the model instead of"+
+		" -opponent\n\t")
+	threshold := fs.Float64("threshold", .6, "Win rate against a curriculum"+
+		" stage needed to advance to the next\n\t")
+	leagueSize := fs.Int("league", 5, "Most snapshots kept in the league"+
+		" of the last curriculum stage\n\t")
+	applyRL := reinforcementFlags(fs)
+	loadRules := rulesFlags(fs)
+
+	fs.Parse(args)
+	err := applyRL()
+	if err == nil {
+		err = loadRules()
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *evalEvery < 1 || *leagueSize < 1 {
+		fmt.Println(errors.New("Evaluations and the league need at least" +
+			" 1 match and snapshot"))
+		os.Exit(1)
+	}
+	if *evalMatches < 1 {
+		fmt.Println(errors.New("Evaluations need at least 1 match to play"))
+		os.Exit(1)
+	}
+
+	rand.Seed(time.Now().Unix())
+
+	op, err := game.NewAgent(*opponent)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *in == "" {
+		*in = *model
+	}
+	if *out == "" {
+		*out = *in
+	}
+
+	var a game.Snapshotter
+	var policy game.Agent
+	train := func(n int) { game.SelfPlay(a, op, n) }
+	switch *model {
+	case "qtable":
+		r, err := game.LoadReinforcement(*in, game.UPDATE_RULE)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		r.File = *out
+		a, policy = r, game.Greedy{Scorer: r}
+		if *workers > 1 {
+			train = func(n int) {
+				game.ParallelSelfPlay(r, func() game.Agent {
+					op, _ := game.NewAgent(*opponent)
+					return op
+				}, n, *workers, *mergeEvery)
+			}
+		}
+	case "dqn":
+		d, err := game.LoadDQN(*in)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		d.File = *out
+		a, policy = d, game.Greedy{Scorer: d}
+	default:
+		fmt.Println("Model unrecognized, run with flag -h for help")
+		os.Exit(1)
+	}
+
+	if *curriculum {
+		trainCurriculum(a, *out, *episodes, game.CurriculumConfig{
+			Threshold:   float32(*threshold),
+			EvalEvery:   *evalEvery,
+			EvalMatches: *evalMatches,
+			LeagueSize:  *leagueSize,
+		})
+		return
+	}
+
+	randAgent, _ := game.NewAgent("rand")
+	minMaxAgent, _ := game.NewAgent("minmax")
+	for done := 0; done < *episodes; done += *evalEvery {
+		n := *evalEvery
+		if done+n > *episodes {
+			n = *episodes - done
+		}
+		train(n)
+
+		winRates := map[string]float32{
+			"rand":   game.Evaluate(policy, randAgent, *evalMatches),
+			"minmax": game.Evaluate(policy, minMaxAgent, *evalMatches),
+		}
+		game.METRICS.Evaluation("", winRates)
+		fmt.Printf("episode %d: win rate %.3f vs rand, %.3f vs minmax\n",
+			done+n, winRates["rand"], winRates["minmax"])
+	}
+
+	err = a.Save()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// checkpoint is one line of the log of the checkpoints saved while training
+// with a curriculum
+type checkpoint struct {
+	File    string
+	Episode int
+	Stage   string
+	WinRate float32
+}
+
+// trainCurriculum trains a with a curriculum, saving it to out after every
+// evaluation and to out-<stage> when a stage is completed. Every checkpoint
+// is logged with its stage to out-checkpoints.jsonl
+func trainCurriculum(a game.Snapshotter, out string, episodes int,
+	cfg game.CurriculumConfig) {
+	f, err := os.OpenFile(out+"-checkpoints.jsonl",
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+
+	game.TrainCurriculum(a, episodes, cfg, func(r game.CurriculumReport) {
+		game.METRICS.Evaluation(r.Stage,
+			map[string]float32{r.Stage: r.WinRate})
+		fmt.Printf("episode %d: stage %s win rate %.3f\n", r.Episode,
+			r.Stage, r.WinRate)
+
+		files := []string{out}
+		if r.Advanced {
+			files = append(files, out+"-"+r.Stage)
+			fmt.Printf("stage %s completed\n", r.Stage)
+		}
+		for _, fn := range files {
+			err := saveAs(a, fn)
+			if err == nil {
+				err = enc.Encode(checkpoint{fn, r.Episode, r.Stage, r.WinRate})
+			}
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+	})
+}
+
+// saveAs saves learner a to file fn instead of its own file
+func saveAs(a game.Learner, fn string) error {
+	switch m := a.(type) {
+	case *game.Reinforcement:
+		file := m.File
+		m.File = fn
+		defer func() { m.File = file }()
+	case *game.DQN:
+		file := m.File
+		m.File = fn
+		defer func() { m.File = file }()
+	}
+	return a.Save()
+}
@@ -3,12 +3,13 @@ package web
 import (
 	"bufio"
 	"encoding/gob"
-	"errors"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
@@ -19,11 +20,14 @@ import (
 )
 
 const (
-	FILE_DIR = "./web/assets/"
-	SAVE_DIR = "./saves/"
-	IMG_DIR  = SAVE_DIR + "imgs/"
-	CHAR_DIR = SAVE_DIR + "characters/"
-	LOG_DIR  = SAVE_DIR + "logs/"
+	FILE_DIR    = "./web/assets/"
+	SAVE_DIR    = "./saves/"
+	IMG_DIR     = SAVE_DIR + "imgs/"
+	CHAR_DIR    = SAVE_DIR + "characters/"
+	LOG_DIR     = SAVE_DIR + "logs/"
+	RECORD_DIR  = SAVE_DIR + "records/"
+	EXPLAIN_DIR = SAVE_DIR + "explain/"
+	MATCH_DIR   = SAVE_DIR + "matches/"
 )
 
 var enemyMap map[string]string
@@ -103,35 +107,16 @@ func getMoves(char game.Class) string {
 		panic(err)
 	}
 
+	// each move shows what it does and costs and is disabled when
+	// unaffordable
 	var moves []interface{}
-	switch char.ClassName {
-	case "Knight":
-		moves = []interface{}{
-			"Crushing Blow",
-			"Quick Thrust",
-			"Sword Slash",
-			"Shield",
-			"Counter",
-			"Drink Potion",
-		}
-	case "Archer":
-		moves = []interface{}{
-			"Piercing Shot",
-			"Quick Fire",
-			"Long Shot",
-			"Block",
-			"Dagger",
-			"Apply Bandaid",
-		}
-	case "Wizard":
-		moves = []interface{}{
-			"Lightning",
-			"Arcane Bolt",
-			"Fireball",
-			"Magic Shield",
-			"Counterspell",
-			"Heal",
+	for m := game.HEAVY; m <= game.EVADE; m++ {
+		def := game.MoveOf(&char, m)
+		disabled := ""
+		if !char.CanAfford(m) {
+			disabled = "disabled"
 		}
+		moves = append(moves, def.Summary(), disabled, def.Name)
 	}
 
 	moveFmt := fmt.Sprintf(s, moves...)
@@ -188,35 +173,40 @@ func charToHTML(c game.Class) (string, error) {
 		return "", err
 	}
 
+	stamina := "-"
+	if c.MaxStamina > 0 {
+		stamina = fmt.Sprintf("%d/%d", c.Stamina, c.MaxStamina)
+	}
+
 	s := fmt.Sprintf(
 		html,
 		c.PlayerName, c.ClassName,
 		"Health", c.Health,
+		"Stamina", stamina,
 		"Armor", c.Armor,
 		"Strength", c.Strength,
 		"Dexterity", c.Dexterity,
 		"Intellect", c.Intellect,
+		"Effects", c.EffectsText(),
+		"Level", c.LevelText(),
+		"Record", c.Wins, c.Losses,
+		"Equipment", c.EquipmentText(),
 	)
 
 	return s, nil
 }
 
-// generateChar takes in a class and name and calls game to
-// generate the char and writes to file
-func generateChar(class, name string) error {
-	var char game.Class
-	switch class {
-	case "Knight":
-		char = game.NewKnight(name)
-	case "Archer":
-		char = game.NewArcher(name)
-	case "Wizard":
-		char = game.NewWizard(name)
-	default:
-		return errors.New("Could not parse class type")
+// generateChar takes in a class, name, difficulty of the AI opponent and
+// level and calls game to generate the char and writes to file
+func generateChar(class, name string, diff game.Difficulty, level int) error {
+	char, err := game.NewClass(class, name)
+	if err != nil {
+		return err
 	}
+	char.Difficulty = diff
+	game.Promote(&char, level)
 
-	err := writeCharToFile(char)
+	err = writeCharToFile(char)
 	if err != nil {
 		return err
 	}
@@ -224,6 +214,128 @@ func generateChar(class, name string) error {
 	return nil
 }
 
+// writeExplanation saves the explanation of the last AI move in a match
+func writeExplanation(matchName string, ex game.Explanation) error {
+	body, err := json.Marshal(ex)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(EXPLAIN_DIR+matchName+".json", body, 0666)
+}
+
+// readExplanation loads the explanation of the last AI move in a match
+func readExplanation(matchName string) (game.Explanation, error) {
+	var ex game.Explanation
+
+	body, err := ioutil.ReadFile(EXPLAIN_DIR + matchName + ".json")
+	if err != nil {
+		return ex, err
+	}
+
+	err = json.Unmarshal(body, &ex)
+	return ex, err
+}
+
+// writeMatch saves the rounds of a match
+func writeMatch(matchName string, m game.Match) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(MATCH_DIR+matchName+".json", body, 0666)
+}
+
+// readMatch loads the rounds of a match, matches without saved rounds are
+// starting their first round
+func readMatch(matchName string) (game.Match, error) {
+	body, err := ioutil.ReadFile(MATCH_DIR + matchName + ".json")
+	if os.IsNotExist(err) {
+		return game.NewMatch(), nil
+	}
+	if err != nil {
+		return game.Match{}, err
+	}
+
+	var m game.Match
+	err = json.Unmarshal(body, &m)
+	return m, err
+}
+
+// roundLogName returns the name of the log file of round n of a match, the
+// first round keeps the name logs had before rounds
+func roundLogName(matchName string, n int) string {
+	if n <= 1 {
+		return matchName + ".log"
+	}
+	return fmt.Sprintf("%s.round%d.log", matchName, n)
+}
+
+// explanationToHTML takes in an explanation and returns the html formatted
+// AI thinking panel
+func explanationToHTML(ex game.Explanation) (string, error) {
+	html, err := fileToString("aiThinking.html")
+	if err != nil {
+		return "", err
+	}
+
+	played := ex.Move
+	switch {
+	case ex.Weakened:
+		played += " (weakened by difficulty)"
+	case ex.Exhausted:
+		played += " (out of stamina)"
+	case ex.Best == "":
+	case ex.Explored:
+		played += " (explored)"
+	default:
+		played += " (exploited)"
+	}
+
+	var rows string
+	for _, ms := range ex.Scores {
+		name := ms.Move
+		if name == ex.Best {
+			name += " *"
+		}
+		rows += fmt.Sprintf("<tr><td>%s</td><td>%.3f</td></tr>\n",
+			name, ms.Score)
+	}
+
+	s := fmt.Sprintf(html, ex.Agent, ex.Difficulty, ex.State, played, rows)
+
+	return s, nil
+}
+
+// explain responds with the explanation of the last AI move in a match as
+// json
+func explain(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	c1, err := readCharFromFile(vars["char1"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	c2, err := readCharFromFile(vars["char2"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	matchName := fmt.Sprintf("%s%s%s%s",
+		c1.PlayerName, c1.ClassName, c2.PlayerName, c2.ClassName)
+	body, err := ioutil.ReadFile(EXPLAIN_DIR + matchName + ".json")
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
 // parseMoveForm processes which move to execute and calls
 // backend in game
 func parseMoveForm(w http.ResponseWriter, r *http.Request) {
@@ -261,9 +373,85 @@ func parseMoveForm(w http.ResponseWriter, r *http.Request) {
 		move = game.EVADE
 	}
 
+	// moves the player can't afford and moves after the match ended are
+	// ignored, this only happens when the url is entered by hand
+	if !c1.CanAfford(move) || c1.Health <= 0 || c2.Health <= 0 {
+		http.Redirect(w, r, "/game/"+char1Name+"/"+char2Name,
+			http.StatusFound)
+		return
+	}
+
+	takeTurn(w, r, c1, c2, move, "")
+}
+
+// takeTurn plays a turn of c1 making move m, or using the consumable called
+// item in its place, against the AI playing c2, records and logs it and
+// redirects to the next screen
+func takeTurn(w http.ResponseWriter, r *http.Request, c1, c2 game.Class,
+	move game.Move, item string) {
+	char1Name := mux.Vars(r)["char1"]
+	char2Name := mux.Vars(r)["char2"]
+
+	matchName := fmt.Sprintf("%s%s%s%s",
+		c1.PlayerName, c1.ClassName, c2.PlayerName, c2.ClassName)
+	match, err := readMatch(matchName)
+	if err != nil {
+		fmt.Printf("Could not read match %s\n", matchName)
+		w.WriteHeader(http.StatusInternalServerError)
+		panic(err)
+	}
+	round := match.Round()
+
 	// process turn and get result
-	enemyMove := game.AIGetTurn(&c1, &c2)
-	outStr, end := game.Turn(&c1, &c2, move, enemyMove)
+	before1, before2 := c1, c2
+	enemyMove, explanation := game.AIGetTurn(&c1, &c2)
+	var outStr string
+	var end bool
+	var hits game.TurnHits
+	if item == "" {
+		outStr, end, hits = game.Turn(&c1, &c2, move, enemyMove)
+	} else {
+		outStr, end, hits, err = game.ItemTurn(&c1, &c2, item, enemyMove)
+		if err != nil {
+			fmt.Printf("Could not use %s\n", item)
+			w.WriteHeader(http.StatusInternalServerError)
+			panic(err)
+		}
+		// characters using items are drawn defending
+		move = game.BLOCK
+	}
+	match.Turns++
+
+	// record turn for training, every round is an episode of its own
+	err = game.AppendRecord(RECORD_DIR+matchName+".jsonl", game.TurnRecord{
+		Match:        fmt.Sprintf("%s.round%d", matchName, round),
+		Player:       before1,
+		AI:           before2,
+		PlayerMove:   move,
+		PlayerItem:   item,
+		AIMove:       enemyMove,
+		PlayerHit:    hits.Kind1,
+		AIHit:        hits.Kind2,
+		PlayerDamage: hits.Damage1,
+		AIDamage:     hits.Damage2,
+		NextPlayer:   c1,
+		NextAI:       c2,
+		End:          end,
+		PlayerWon:    end && c1.Health > 0,
+	})
+	if err != nil {
+		fmt.Printf("Could not write record for %s\n", matchName)
+		w.WriteHeader(http.StatusInternalServerError)
+		panic(err)
+	}
+
+	// explain ai move for the thinking panel
+	err = writeExplanation(matchName, explanation)
+	if err != nil {
+		fmt.Printf("Could not write explanation for %s\n", matchName)
+		w.WriteHeader(http.StatusInternalServerError)
+		panic(err)
+	}
 
 	// write turns to file
 	err = setImages(c1, c2, move, enemyMove)
@@ -273,12 +461,37 @@ func parseMoveForm(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 
+	// the match goes on with restored characters until a character won most
+	// of its rounds
+	var over, won bool
+	if end {
+		outStr += match.EndRound(&c1, &c2)
+		over = match.Over()
+		won = match.Winner(&c1, &c2) == c1.PlayerName
+	}
+	err = writeMatch(matchName, match)
+	if err != nil {
+		fmt.Printf("Could not write match %s\n", matchName)
+		w.WriteHeader(http.StatusInternalServerError)
+		panic(err)
+	}
+
+	// watch for degenerate strategies, which items are no part of
+	if item == "" {
+		game.ObservePlayerMove(&before1, move, over, won)
+	} else {
+		game.ObservePlayerItem(&before1, over, won)
+	}
+
+	// award experience once the match is over
+	if over {
+		outStr += game.AwardMatch(&c1, &c2, won)
+	}
+
 	// divwrap result
 	res := divWrap(outStr)
-	// get log file name
-	logName := fmt.Sprintf("%s%s%s%s.log",
-		c1.PlayerName, c1.ClassName, c2.PlayerName, c2.ClassName)
-	// add result to log file
+	// add result to the log file of the round
+	logName := roundLogName(matchName, round)
 	err = logFile(logName, res)
 	if err != nil {
 		fmt.Printf("Could not write to log file %s", logName)
@@ -300,7 +513,9 @@ func parseMoveForm(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var redirect string
-	if end {
+	if over {
+		game.RecordResult(c1.PlayerName, won)
+		game.RecordBanditResult(&c1, &c2)
 		redirect = "/end/" + char1Name + "/" + char2Name
 	} else {
 		redirect = "/game/" + char1Name + "/" + char2Name
@@ -308,6 +523,18 @@ func parseMoveForm(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, redirect, http.StatusFound)
 }
 
+// classButtons returns the html of a submit button for every class in the
+// registry
+func classButtons() string {
+	var buttons string
+	for _, def := range game.Classes() {
+		buttons += fmt.Sprintf(
+			"    <input type=\"submit\" name=\"class\" value=\"%s\">\n",
+			def.Name)
+	}
+	return buttons
+}
+
 // parseNewCharForm handles extracting the name and class from character
 // creation screen and generating a new character with that info
 func parseNewCharForm(w http.ResponseWriter, r *http.Request) {
@@ -320,6 +547,12 @@ func parseNewCharForm(w http.ResponseWriter, r *http.Request) {
 
 	class := r.Form.Get("class")
 	name := r.Form.Get("name")
+	diff, err := game.ParseDifficulty(r.Form.Get("difficulty"))
+	if err != nil {
+		fmt.Printf("Could not parse difficulty\n")
+		w.WriteHeader(http.StatusBadRequest)
+		panic(err)
+	}
 
 	if name == "" {
 		style, err := fileToString("styleHead.html")
@@ -334,10 +567,10 @@ func parseNewCharForm(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusInternalServerError)
 			panic(err)
 		}
-		fmt.Fprint(w, style+body)
+		fmt.Fprint(w, style+fmt.Sprintf(body, classButtons()))
 		fmt.Fprint(w, `<h3 style="color:red">Character name cannot be empty!</h3>`)
 	} else {
-		err = generateChar(class, name)
+		err = generateChar(class, name, diff, 1)
 		if err != nil {
 			fmt.Printf("Could not parse character type\n")
 			w.WriteHeader(http.StatusInternalServerError)
@@ -364,7 +597,7 @@ func newCharacterScreen(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 
-	s = style + s
+	s = style + fmt.Sprintf(s, classButtons())
 
 	fmt.Fprint(w, s)
 }
@@ -431,14 +664,21 @@ func getOpponent(character string) string {
 		}
 		names := strings.SplitN(nameStr, ",", -1)
 
+		// opponents are generated at the level of the character
+		c, err := readCharFromFile(character)
+		if err != nil {
+			panic(err)
+		}
+
 		for {
-			opClass := []string{"Knight", "Archer", "Wizard"}[rand.Intn(3)]
+			classes := game.Classes()
+			opClass := classes[rand.Intn(len(classes))].Name
 			opName := fmt.Sprintf("%s_enemy", names[rand.Intn(len(names))])
 			opponent = opName + "." + opClass
 
 			_, err = os.Stat(SAVE_DIR + opponent)
 			if os.IsNotExist(err) {
-				err = generateChar(opClass, opName)
+				err = generateChar(opClass, opName, game.DIFF_HARD, c.Level)
 				if err != nil {
 					panic(err)
 				}
@@ -525,6 +765,15 @@ func characterSelectScreen(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprint(w, `</table></body>`)
 }
 
+// sprite returns the prefix of the sprite images of the class of c
+func sprite(c game.Class) string {
+	def, ok := game.LookupClass(c.ClassName)
+	if !ok {
+		return c.ClassName
+	}
+	return def.Sprite
+}
+
 func setImages(c1, c2 game.Class, m1, m2 game.Move) error {
 	filePrefix := c1.PlayerName + c1.ClassName + c2.PlayerName + c2.ClassName
 
@@ -570,8 +819,8 @@ func setImages(c1, c2 game.Class, m1, m2 game.Move) error {
 
 	fmt.Fprint(
 		f,
-		c1.ClassName+"-"+m1Str+".png"+"\n",
-		c2.ClassName+"-"+m2Str+".png"+"\n",
+		sprite(c1)+"-"+m1Str+".png"+"\n",
+		sprite(c2)+"-"+m2Str+".png"+"\n",
 	)
 
 	return nil
@@ -592,8 +841,8 @@ func getImageStrings(c1, c2 game.Class) ([]string, error) {
 			}
 			fmt.Fprint(
 				f,
-				c1.ClassName+"-"+"IDLE"+".png"+"\n",
-				c2.ClassName+"-"+"IDLE"+".png"+"\n",
+				sprite(c1)+"-"+"IDLE"+".png"+"\n",
+				sprite(c2)+"-"+"IDLE"+".png"+"\n",
 			)
 			f.Close()
 			f, err = os.Open(fn)
@@ -664,13 +913,23 @@ func gameScreen(w http.ResponseWriter, r *http.Request) {
 
 	screen = style + screen
 
-	// get log file name
-	logName := fmt.Sprintf("%s%s%s%s.log",
+	// only the log of the current round is shown
+	matchName := fmt.Sprintf("%s%s%s%s",
 		c1.PlayerName, c1.ClassName, c2.PlayerName, c2.ClassName)
-	gameLog, err := loadLog(logName)
+	match, err := readMatch(matchName)
+	if err != nil {
+		panic(err)
+	}
+	gameLog, err := loadLog(roundLogName(matchName, match.Round()))
 	if err != nil {
 		gameLog = ""
 	}
+	if match.BestOf > 1 {
+		gameLog = fmt.Sprintf("<h3>Round %d of %d, %s %d-%d %s</h3>",
+			match.Round(), match.BestOf, c1.PlayerName,
+			match.Wins(c1.PlayerName), match.Wins(c2.PlayerName),
+			c2.PlayerName) + gameLog
+	}
 
 	rthButton, err := fileToString("returnToHomeButton.html")
 	if err != nil {
@@ -680,14 +939,24 @@ func gameScreen(w http.ResponseWriter, r *http.Request) {
 	// screen += "<br>" + rthButton + "<br>" + gameLog
 	screen += "<br>" + gameLog
 
-	c1Moves := getMoves(c1)
+	c1Moves := getMoves(c1) + inventoryToHTML(c1, cn1, cn2, true)
+
+	// add AI thinking panel once the AI has made a move
+	ex, err := readExplanation(matchName)
+	if err == nil {
+		thinking, err := explanationToHTML(ex)
+		if err != nil {
+			panic(err)
+		}
+		c1Moves += thinking
+	}
 
-	info := "Heavy attacks effective against low int (str damage)\n<br>" +
-		"Quick attacks effective against low str (dex damage)\n<br>" +
-		"Standard attacks effective against low dex (int damage)\n<br>" +
-		"Blocks effective with high str, heals armor\n<br>" +
-		"Parry effective with high dex, counters but can backfire\n<br>" +
-		"Evade effective with high int, heals HP\n<br>"
+	info := "Attacks land against a low \"vs\" attribute and hit harder" +
+		" with a high damage attribute\n<br>" +
+		"Defenses succeed with a high attribute of their own\n<br>" +
+		"Blocks repair armor\n<br>" +
+		"Parries counter but can backfire\n<br>" +
+		"Evades heal HP\n<br>"
 	info = divWrap(info)
 
 	info += "<br><br>" + rthButton
@@ -720,12 +989,23 @@ func gameEnd(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 
-	// get log file name
-	logName := fmt.Sprintf("%s%s%s%s.log",
+	// summary of the match followed by the logs of its rounds, latest first
+	matchName := fmt.Sprintf("%s%s%s%s",
 		c1.PlayerName, c1.ClassName, c2.PlayerName, c2.ClassName)
-	gameLog, err := loadLog(logName)
+	match, err := readMatch(matchName)
 	if err != nil {
-		gameLog = ""
+		panic(err)
+	}
+	gameLog := "<h3>Match summary</h3>" + divWrap(match.Summary(&c1, &c2))
+	for n := len(match.Rounds); n >= 1; n-- {
+		roundLog, err := loadLog(roundLogName(matchName, n))
+		if err != nil {
+			continue
+		}
+		if len(match.Rounds) > 1 {
+			roundLog = fmt.Sprintf("<h3>Round %d</h3>", n) + roundLog
+		}
+		gameLog += roundLog
 	}
 
 	//get button to rth
@@ -734,12 +1014,181 @@ func gameEnd(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 
-	screen := style + button + "<br>" + gameLog
+	progress, err := progressToHTML(c1, cn1, cn2)
+	if err != nil {
+		panic(err)
+	}
+
+	screen := style + button + "<br>" + progress + "<br>" + gameLog
 
 	fmt.Fprint(w, screen)
 }
 
-func deleteChar(w http.ResponseWriter, r *http.Request) {
+// progressToHTML returns the progression panel of the end screen, offering
+// the unspent points of character c stored in cn1 and the next match
+func progressToHTML(c game.Class, cn1, cn2 string) (string, error) {
+	html, err := fileToString("progressPanel.html")
+	if err != nil {
+		return "", err
+	}
+
+	cHTML, err := charToHTML(c)
+	if err != nil {
+		return "", err
+	}
+
+	var buttons string
+	if c.Points > 0 {
+		for _, attr := range []string{"Strength", "Dexterity", "Intellect"} {
+			buttons += fmt.Sprintf("<form action=\"/allocate/%s/%s/%s\">"+
+				"<input type=\"submit\" value=\"+ %s\"></form>\n",
+				cn1, cn2, attr, attr)
+		}
+	}
+
+	buttons += inventoryToHTML(c, cn1, cn2, false)
+
+	title := fmt.Sprintf("%s is level %s", c.PlayerName, c.LevelText())
+	return fmt.Sprintf(html, title, cHTML, buttons, cn1, cn2), nil
+}
+
+// inventoryToHTML returns the inventory of character c stored in cn1, with
+// buttons using consumables during a live match and equipping weapons and
+// armor between matches
+func inventoryToHTML(c game.Class, cn1, cn2 string, live bool) string {
+	button := "<form action=\"/%s/%s/%s/%s\">" +
+		"<input type=\"submit\" value=\"%s\"></form>\n"
+
+	var res string
+	if !live {
+		for _, kind := range []string{game.WEAPON, game.GEAR} {
+			name := c.Weapon
+			if kind == game.GEAR {
+				name = c.Gear
+			}
+			if name != "" {
+				res += fmt.Sprintf(button, "unequip", cn1, cn2, kind,
+					"Unequip "+name)
+			}
+		}
+	}
+	for _, def := range game.InventoryItems(&c) {
+		switch {
+		case live && def.Kind == game.CONSUMABLE:
+			res += fmt.Sprintf(button, "use", cn1, cn2,
+				url.PathEscape(def.Name), "Use "+def.Name)
+		case !live && def.Kind != game.CONSUMABLE:
+			res += fmt.Sprintf(button, "equip", cn1, cn2,
+				url.PathEscape(def.Name), "Equip "+def.Name)
+		default:
+			res += "<div>" + def.Name + "</div>\n"
+		}
+	}
+	if res == "" {
+		return ""
+	}
+	return "<h3>Inventory</h3>\n" + res
+}
+
+// useItem uses a consumable of the character in place of its move during a
+// live match, taking its turn while the AI plays its move
+func useItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	cn1 := vars["char1"]
+	cn2 := vars["char2"]
+
+	c1, err := readCharFromFile(cn1)
+	if err != nil {
+		panic(err)
+	}
+	c2, err := readCharFromFile(cn2)
+	if err != nil {
+		panic(err)
+	}
+
+	// items can't be used after the match, while stunned or without
+	// carrying them, so errors only come from urls entered by hand and are
+	// ignored
+	if c1.Health <= 0 || c2.Health <= 0 ||
+		game.CanUseItem(&c1, vars["item"]) != nil {
+		http.Redirect(w, r, "/game/"+cn1+"/"+cn2, http.StatusFound)
+		return
+	}
+
+	takeTurn(w, r, c1, c2, game.BLOCK, vars["item"])
+}
+
+// equipItem equips a weapon or armor piece of the character between matches
+func equipItem(w http.ResponseWriter, r *http.Request) {
+	changeEquipment(w, r, func(c *game.Class) error {
+		return game.Equip(c, mux.Vars(r)["item"])
+	})
+}
+
+// unequipItem puts the equipped item of a kind back in the inventory of the
+// character between matches
+func unequipItem(w http.ResponseWriter, r *http.Request) {
+	changeEquipment(w, r, func(c *game.Class) error {
+		return game.Unequip(c, mux.Vars(r)["kind"])
+	})
+}
+
+// changeEquipment applies change to the character once its match is over
+// and returns to the end screen
+func changeEquipment(w http.ResponseWriter, r *http.Request,
+	change func(c *game.Class) error) {
+	vars := mux.Vars(r)
+	cn1 := vars["char1"]
+	cn2 := vars["char2"]
+
+	c1, err := readCharFromFile(cn1)
+	if err != nil {
+		panic(err)
+	}
+
+	// equipment can't change mid match, so errors only come from urls
+	// entered by hand and are ignored
+	c2, err := readCharFromFile(cn2)
+	if err == nil && (c1.Health <= 0 || c2.Health <= 0) &&
+		change(&c1) == nil {
+		err = writeCharToFile(c1)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	http.Redirect(w, r, "/end/"+cn1+"/"+cn2, http.StatusFound)
+}
+
+// allocate spends a point of the character on the attribute chosen on the
+// end screen
+func allocate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	cn1 := vars["char1"]
+	cn2 := vars["char2"]
+
+	c1, err := readCharFromFile(cn1)
+	if err != nil {
+		panic(err)
+	}
+
+	// points can't be spent mid match or twice, so errors only come from
+	// urls entered by hand and are ignored
+	c2, err := readCharFromFile(cn2)
+	if err == nil && (c1.Health <= 0 || c2.Health <= 0) &&
+		game.Allocate(&c1, vars["attr"]) == nil {
+		err = writeCharToFile(c1)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	http.Redirect(w, r, "/end/"+cn1+"/"+cn2, http.StatusFound)
+}
+
+// nextMatch restores the character after a finished match and matches it
+// against a new opponent of its level
+func nextMatch(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	cn1 := vars["char1"]
 	cn2 := vars["char2"]
@@ -754,7 +1203,14 @@ func deleteChar(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 
-	err = os.Remove(CHAR_DIR + cn1)
+	// only finished matches can be left
+	if c1.Health > 0 && c2.Health > 0 {
+		http.Redirect(w, r, "/game/"+cn1+"/"+cn2, http.StatusFound)
+		return
+	}
+
+	game.Restore(&c1)
+	err = writeCharToFile(c1)
 	if err != nil {
 		panic(err)
 	}
@@ -763,16 +1219,60 @@ func deleteChar(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		panic(err)
 	}
+	removeMatchFiles(c1, c2)
 
-	logName := fmt.Sprintf("%s%s%s%s.log",
-		c1.PlayerName, c1.ClassName, c2.PlayerName, c2.ClassName)
+	if !enemyMapLoaded {
+		initEnemyMap()
+	}
+	enemyMapLock.Lock()
+	delete(enemyMap, cn1)
+	enemyMapLock.Unlock()
+	opponent := getOpponent(cn1)
 
-	os.Remove(LOG_DIR + logName)
+	http.Redirect(w, r, "/game/"+cn1+"/"+opponent, http.StatusFound)
+}
 
-	imagesName := fmt.Sprintf("%s%s%s%s.images",
+// removeMatchFiles removes the round logs, images, explanation and rounds of
+// the match of c1 against c2
+func removeMatchFiles(c1, c2 game.Class) {
+	match := fmt.Sprintf("%s%s%s%s",
 		c1.PlayerName, c1.ClassName, c2.PlayerName, c2.ClassName)
 
-	os.Remove(IMG_DIR + imagesName)
+	rounds, _ := readMatch(match)
+	for n := 1; n <= rounds.Round(); n++ {
+		os.Remove(LOG_DIR + roundLogName(match, n))
+	}
+	os.Remove(MATCH_DIR + match + ".json")
+	os.Remove(IMG_DIR + match + ".images")
+	os.Remove(EXPLAIN_DIR + match + ".json")
+}
+
+func deleteChar(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	cn1 := vars["char1"]
+	cn2 := vars["char2"]
+
+	c1, err := readCharFromFile(cn1)
+	if err != nil {
+		panic(err)
+	}
+
+	c2, err := readCharFromFile(cn2)
+	if err != nil {
+		panic(err)
+	}
+
+	err = os.Remove(CHAR_DIR + cn1)
+	if err != nil {
+		panic(err)
+	}
+
+	err = os.Remove(CHAR_DIR + cn2)
+	if err != nil {
+		panic(err)
+	}
+
+	removeMatchFiles(c1, c2)
 
 	http.Redirect(w, r, "/selectChar", http.StatusFound)
 }
@@ -797,6 +1297,12 @@ func newRouter() *mux.Router {
 	r.HandleFunc("/turn/{char1}/{char2}/{move}", parseMoveForm)
 	r.HandleFunc("/game/{char1}/{char2}", gameScreen)
 	r.HandleFunc("/end/{char1}/{char2}", gameEnd)
+	r.HandleFunc("/explain/{char1}/{char2}", explain).Methods("GET")
+	r.HandleFunc("/allocate/{char1}/{char2}/{attr}", allocate)
+	r.HandleFunc("/next/{char1}/{char2}", nextMatch)
+	r.HandleFunc("/use/{char1}/{char2}/{item}", useItem)
+	r.HandleFunc("/equip/{char1}/{char2}/{item}", equipItem)
+	r.HandleFunc("/unequip/{char1}/{char2}/{kind}", unequipItem)
 
 	return r
 }